@@ -0,0 +1,657 @@
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config mimicking Ruby's behavior
+var DangerWords = []string{"矛盾", "ふざけるな", "嘘", "絶対", "違う", "変", "おかしい"}
+
+// defaultDangerWeights lists DangerWords with no weight of their own.
+func defaultDangerWeights() map[string]float64 {
+	m := make(map[string]float64, len(DangerWords))
+	for _, w := range DangerWords {
+		m[w] = 0
+	}
+	return m
+}
+
+// BrainConfig sets the dramatic pacing of the Brain.
+type BrainConfig struct {
+	DangerWords map[string]float64 `json:"danger_words"` // word -> weight (0 = danger_bump), merged over the built-ins
+
+	AlignedThreshold float64 `json:"aligned_threshold"` // tension above -> ALIGNED
+	SplitThreshold   float64 `json:"split_threshold"`   // tension above -> SPLIT
+	AlignedExit      float64 `json:"aligned_exit"`      // leave ALIGNED only below this
+	SplitExit        float64 `json:"split_exit"`        // leave SPLIT only below this
+	DangerBump       float64 `json:"danger_bump"`       // weight for danger words listed without one
+	DangerCap        float64 `json:"danger_cap"`        // max danger tension per utterance
+	ActivityBump     float64 `json:"activity_bump"`     // per ordinary utterance
+	DecayPerSec      float64 `json:"decay_per_sec"`
+
+	// Interruptions: speaker flips within InterruptGap seconds of the last
+	// utterance; InterruptFlips of them inside InterruptWindow add InterruptBonus each
+	InterruptGap    float64 `json:"interrupt_gap"`
+	InterruptWindow float64 `json:"interrupt_window"`
+	InterruptFlips  int     `json:"interrupt_flips"`
+	InterruptBonus  float64 `json:"interrupt_bonus"` // 0 = off
+
+	// Monologue: one speaker holding the floor for MonologueAfter seconds;
+	// a pause longer than MonologuePause ends it
+	MonologueAfter float64 `json:"monologue_after"` // 0 = off
+	MonologuePause float64 `json:"monologue_pause"`
+
+	// Seconds after a flash during which further impacts only add
+	// tension, without flash or shake (0 = every impact hits)
+	ImpactCooldown float64 `json:"impact_cooldown"`
+
+	// The look of an impact word: screen shake, flash, scale and color
+	ImpactShake float64 `json:"impact_shake"`
+	ImpactFlash bool    `json:"impact_flash"`
+	ImpactScale float64 `json:"impact_scale"`
+	ImpactColor string  `json:"impact_color"`
+
+	// Resolution: an agreement word (with tension above AlignedExit or a
+	// SPLIT unresolved), or ResolveCalm seconds of silence after a SPLIT,
+	// multiplies decay by ResolveDecay for ResolveDuration
+	ResolveWords    []string `json:"resolve_words"`
+	ResolveCalm     float64  `json:"resolve_calm"` // 0 = words only
+	ResolveDecay    float64  `json:"resolve_decay"`
+	ResolveDuration float64  `json:"resolve_duration"`
+
+	// Thinking: HesitationCluster hesitations inside HesitationWindow
+	// seconds turn the last one into a "thinking" cluster (0 = off)
+	HesitationCluster int     `json:"hesitation_cluster"`
+	HesitationWindow  float64 `json:"hesitation_window"`
+
+	// Let the silence escalation (沈黙, 静寂, ...) run during SPLIT
+	SilenceInSplit bool `json:"silence_in_split"`
+
+	// Agreement: each agreement word adds AlignGain to Alignment (0..1).
+	// It fades at AlignFade per second, faster the higher the tension,
+	// and a danger word clears it
+	AlignGain float64 `json:"align_gain"` // 0 = off
+	AlignFade float64 `json:"align_fade"`
+}
+
+func DefaultBrainConfig() BrainConfig {
+	return BrainConfig{
+		AlignedThreshold:  2.0,
+		SplitThreshold:    8.0,
+		AlignedExit:       1.5,
+		SplitExit:         6.0,
+		DangerWords:       defaultDangerWeights(),
+		DangerBump:        3.0,
+		DangerCap:         6.0,
+		ActivityBump:      0.2,
+		DecayPerSec:       0.5,
+		InterruptGap:      0.8,
+		InterruptWindow:   10,
+		InterruptFlips:    2,
+		InterruptBonus:    1.0,
+		MonologueAfter:    30,
+		MonologuePause:    3,
+		ImpactCooldown:    1.5,
+		ImpactShake:       20.0,
+		ImpactFlash:       true,
+		ImpactScale:       2.5,
+		ImpactColor:       "red",
+		ResolveWords:      []string{"確かに", "なるほど", "そうだね", "同感", "賛成", "ごめん"},
+		ResolveCalm:       15,
+		ResolveDecay:      4,
+		ResolveDuration:   8,
+		HesitationCluster: 3,
+		HesitationWindow:  8,
+		AlignGain:         0.35,
+		AlignFade:         0.03,
+	}
+}
+
+// Validate checks that the thresholds are ordered and rates are sane.
+func (c BrainConfig) Validate() error {
+	if c.AlignedThreshold < 0 || c.SplitThreshold <= c.AlignedThreshold {
+		return fmt.Errorf("brain: need 0 <= aligned_threshold (%.2f) < split_threshold (%.2f)",
+			c.AlignedThreshold, c.SplitThreshold)
+	}
+	if c.AlignedExit < 0 || c.AlignedExit > c.AlignedThreshold ||
+		c.SplitExit > c.SplitThreshold || c.SplitExit < c.AlignedExit {
+		return fmt.Errorf("brain: need 0 <= aligned_exit <= aligned_threshold, aligned_exit <= split_exit <= split_threshold")
+	}
+	if c.DecayPerSec < 0 || c.DangerBump < 0 || c.DangerCap < 0 || c.ActivityBump < 0 || c.InterruptBonus < 0 || c.ResolveDecay < 0 ||
+		c.AlignGain < 0 || c.AlignFade < 0 || c.ImpactShake < 0 {
+		return fmt.Errorf("brain: bumps and decay must not be negative")
+	}
+	return nil
+}
+
+type Brain struct {
+	Config BrainConfig
+
+	Tension        float64
+	LastUpdate     time.Time
+	LastSpeechTime time.Time
+	SilenceStage   int
+	SilenceFrom    time.Time // silence is timed from here if later than LastSpeechTime
+
+	// Clock (swap for a fixed clock to make timing deterministic)
+	Now func() time.Time
+
+	// Show-specific keywords that always pop (keyword -> look)
+	Highlights map[string]Highlight
+
+	// Most recent utterances, oldest first
+	RecentWords []string
+
+	// Turn taking, for interruption detection
+	Speaker       int
+	LastTurn      time.Time
+	TurnStart     time.Time   // when the current speaker took the floor
+	Interruptions []time.Time // fast speaker flips inside the window
+
+	LastImpact time.Time // last impact that was allowed to flash
+
+	Hesitations []time.Time // recent hesitations, for thinking clusters
+
+	// Reconciliation: Unresolved is set by a SPLIT and cleared by Resolve
+	Unresolved     bool
+	ResolvingUntil time.Time
+	OnResolve      func()
+
+	Alignment float64 // 0..1, built up by agreement
+
+	// State tracking: OnStateChange fires when the computed state changes
+	State         string
+	OnStateChange func(StateChange)
+}
+
+// StateChange is emitted when the Brain moves between UNKNOWN/ALIGNED/SPLIT.
+type StateChange struct {
+	From, To string
+	At       time.Time
+}
+
+const maxRecentWords = 20
+
+// Highlight is the forced look for a performer-chosen keyword.
+type Highlight struct {
+	Color string  `json:"color"`
+	Scale float64 `json:"scale"`
+	Exact bool    `json:"exact"` // whole utterance must equal the keyword
+}
+
+func NewBrain(cfg BrainConfig) *Brain {
+	return NewBrainWithClock(cfg, time.Now)
+}
+
+func NewBrainWithClock(cfg BrainConfig, now func() time.Time) *Brain {
+	return &Brain{
+		Config:         cfg,
+		State:          "UNKNOWN",
+		LastUpdate:     now(),
+		LastSpeechTime: now(),
+		Now:            now,
+	}
+}
+
+type WordConfig struct {
+	Text   string
+	Style  string
+	Scale  float64
+	ScaleX float64
+	Rot    float64
+	Color  string
+	VY     float64
+	VYMult float64
+	Flash  bool
+	Shake  float64
+
+	// Part of a split phrase (keeps the current speaker)
+	Continuation bool
+}
+
+// Default config
+func NewWordConfig(text string) WordConfig {
+	return WordConfig{
+		Text:   text,
+		Style:  "normal",
+		Scale:  1.0,
+		ScaleX: 1.0,
+		Rot:    0.0,
+		Color:  "white",
+		VY:     0.0,
+		VYMult: 1.0,
+	}
+}
+
+func (b *Brain) ProcessText(text string) WordConfig {
+	b.LastSpeechTime = b.Now()
+	b.SilenceStage = 0
+
+	b.RecentWords = append(b.RecentWords, text)
+	if len(b.RecentWords) > maxRecentWords {
+		b.RecentWords = b.RecentWords[len(b.RecentWords)-maxRecentWords:]
+	}
+
+	// Tension
+	if danger := b.dangerWeight(text); danger > 0 {
+		b.Tension += danger
+		b.Alignment = 0
+	} else {
+		b.Tension += b.Config.ActivityBump
+	}
+
+	b.Recalculate()
+	if b.agrees(text) {
+		b.Alignment = min(1, b.Alignment+b.Config.AlignGain)
+		// Only an actual conflict resolves; agreeing in a calm room
+		// shouldn't wash the screen every time
+		if b.Unresolved || b.Tension > b.Config.AlignedExit {
+			b.Resolve()
+		}
+	}
+	cfg := b.AnalyzeSemantics(text)
+	if cfg.Style == "hesitation" && b.noteHesitation() {
+		cfg.Style = "thinking"
+		cfg.Color = "grey_alpha"
+	}
+	return b.coolImpact(cfg)
+}
+
+// noteHesitation records a hesitation and reports whether it completes
+// a cluster. The count starts over after each cluster.
+func (b *Brain) noteHesitation() bool {
+	if b.Config.HesitationCluster <= 0 {
+		return false
+	}
+	now := b.Now()
+	window := time.Duration(b.Config.HesitationWindow * float64(time.Second))
+	recent := b.Hesitations[:0]
+	for _, t := range b.Hesitations {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	b.Hesitations = append(recent, now)
+
+	if len(b.Hesitations) < b.Config.HesitationCluster {
+		return false
+	}
+	b.Hesitations = b.Hesitations[:0]
+	return true
+}
+
+func (b *Brain) agrees(text string) bool {
+	for _, w := range b.Config.ResolveWords {
+		if strings.Contains(text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve clears the air: tension decays ResolveDecay times faster for
+// ResolveDuration seconds, and OnResolve fires for the calming cue.
+func (b *Brain) Resolve() {
+	b.Unresolved = false
+	b.ResolvingUntil = b.Now().Add(time.Duration(b.Config.ResolveDuration * float64(time.Second)))
+	if b.OnResolve != nil {
+		b.OnResolve()
+	}
+}
+
+// coolImpact mutes the flash and shake of an impact that lands within
+// ImpactCooldown of the last one, so a burst of danger words hits once.
+func (b *Brain) coolImpact(cfg WordConfig) WordConfig {
+	if !cfg.Flash && cfg.Shake == 0 {
+		return cfg
+	}
+	now := b.Now()
+	if now.Sub(b.LastImpact).Seconds() < b.Config.ImpactCooldown {
+		cfg.Flash = false
+		cfg.Shake = 0
+		return cfg
+	}
+	b.LastImpact = now
+	return cfg
+}
+
+// NoteTurn records who spoke the utterance just processed. A flip to the
+// other speaker right on the heels of the last utterance is an
+// interruption; enough of them close together heat the room up.
+func (b *Brain) NoteTurn(speaker int) {
+	now := b.Now()
+	gap := now.Sub(b.LastTurn).Seconds()
+	flipped := speaker != b.Speaker
+	b.Speaker = speaker
+	b.LastTurn = now
+	if flipped || gap > b.Config.MonologuePause {
+		b.TurnStart = now
+	}
+
+	if b.Config.InterruptBonus <= 0 || !flipped || gap > b.Config.InterruptGap {
+		return
+	}
+
+	window := time.Duration(b.Config.InterruptWindow * float64(time.Second))
+	recent := b.Interruptions[:0]
+	for _, t := range b.Interruptions {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	b.Interruptions = append(recent, now)
+
+	if len(b.Interruptions) >= b.Config.InterruptFlips {
+		b.Tension += b.Config.InterruptBonus
+	}
+}
+
+// Monologue reports whether the current speaker has been talking on
+// their own, without long pauses, for MonologueAfter seconds.
+func (b *Brain) Monologue() bool {
+	if b.Config.MonologueAfter <= 0 || b.Now().Sub(b.LastTurn).Seconds() > b.Config.MonologuePause {
+		return false
+	}
+	return b.LastTurn.Sub(b.TurnStart).Seconds() >= b.Config.MonologueAfter
+}
+
+// dangerWeight sums the weights of the danger words in text, capped per utterance.
+func (b *Brain) dangerWeight(text string) float64 {
+	sum := 0.0
+	for w, weight := range b.Config.DangerWords {
+		if !strings.Contains(text, w) {
+			continue
+		}
+		if weight == 0 {
+			weight = b.Config.DangerBump
+		}
+		sum += weight
+	}
+	if b.Config.DangerCap > 0 {
+		sum = min(sum, b.Config.DangerCap)
+	}
+	return sum
+}
+
+func (b *Brain) AnalyzeSemantics(text string) WordConfig {
+	cfg := NewWordConfig(text)
+
+	// Highlights (take priority over every built-in rule)
+	if h, ok := b.matchHighlight(text); ok {
+		cfg.Style = "highlight"
+		cfg.Color = h.Color
+		if h.Scale != 0 {
+			cfg.Scale = h.Scale
+		}
+		return cfg
+	}
+
+	// Impact Logic
+	impactWords := []string{"絶対", "嘘", "違う", "矛盾", "変", "おかしい"}
+	for _, w := range impactWords {
+		if strings.Contains(text, w) {
+			cfg.Style = "impact"
+			cfg.Flash = b.Config.ImpactFlash
+			cfg.Shake = b.Config.ImpactShake
+			cfg.Color = b.Config.ImpactColor
+			cfg.Scale = b.Config.ImpactScale
+			return cfg
+		}
+	}
+
+	// Inversion Logic
+	if (strings.Contains(text, "上下") || strings.Contains(text, "天井") || strings.Contains(text, "逆さま")) &&
+		(strings.Contains(text, "反転") || strings.Contains(text, "逆")) {
+		cfg.Style = "invert_v"
+		cfg.Rot = 3.14159
+		cfg.VYMult = -1.0
+		cfg.Color = "cyan"
+		return cfg
+	}
+
+	if (strings.Contains(text, "左右") || strings.Contains(text, "鏡")) &&
+		(strings.Contains(text, "反転") || strings.Contains(text, "逆")) {
+		cfg.Style = "invert_h"
+		cfg.ScaleX = -1.0
+		cfg.Color = "cyan"
+		return cfg
+	}
+
+	if (strings.Contains(text, "色") || strings.Contains(text, "カラー")) &&
+		(strings.Contains(text, "反転") || strings.Contains(text, "違う")) {
+		cfg.Style = "invert_c"
+		cfg.Color = "cyan"
+		return cfg
+	}
+
+	// Conjunctions
+	conjunctions := []string{"でも", "しかし", "だが", "逆に", "とは言え", "けど", "反対に"}
+	for _, w := range conjunctions {
+		if strings.Contains(text, w) {
+			cfg.Style = "conjunction"
+			cfg.ScaleX = -1.0
+			cfg.Rot = 3.14159
+			cfg.Color = "yellow"
+			return cfg
+		}
+	}
+
+	// Hesitation
+	hesitations := []string{"えっと", "うーん", "あの", "多分", "かな", "なんか", "えー"}
+	for _, w := range hesitations {
+		if strings.Contains(text, w) {
+			cfg.Style = "hesitation"
+			cfg.Color = "grey"
+			return cfg
+		}
+	}
+
+	return cfg
+}
+
+// matchHighlight finds the highlight for text. When several substring
+// keywords match, the longest wins so the result doesn't depend on map order.
+func (b *Brain) matchHighlight(text string) (Highlight, bool) {
+	best := ""
+	for kw, h := range b.Highlights {
+		hit := strings.Contains(text, kw)
+		if h.Exact {
+			hit = strings.TrimSpace(text) == kw
+		}
+		if hit && len(kw) > len(best) {
+			best = kw
+		}
+	}
+	if best == "" {
+		return Highlight{}, false
+	}
+	return b.Highlights[best], true
+}
+
+func (b *Brain) CheckSilence() (string, WordConfig, bool) {
+	now := b.Now()
+	if b.State == "SPLIT" && !b.Config.SilenceInSplit {
+		// Hold the escalation; it picks up from here once things calm
+		b.SilenceFrom = now
+		return "", WordConfig{}, false
+	}
+	from := b.LastSpeechTime
+	if b.SilenceFrom.After(from) {
+		from = b.SilenceFrom
+	}
+	duration := now.Sub(from).Seconds()
+
+	if duration > 2.0 && b.SilenceStage == 0 {
+		b.SilenceStage = 1
+		cfg := NewWordConfig("...")
+		cfg.Style = "silence_dots"
+		cfg.Color = "grey_alpha"
+		cfg.Scale = 0.8
+		return "...", cfg, true
+	} else if duration > 5.0 && b.SilenceStage == 1 {
+		b.SilenceStage = 2
+		cfg := NewWordConfig("間")
+		cfg.Style = "silence_ma"
+		cfg.Color = "blue_white"
+		cfg.Scale = 1.0
+		return "間", cfg, true
+	} else if duration > 8.0 && b.SilenceStage == 2 {
+		b.SilenceStage = 3
+		cfg := NewWordConfig("沈黙")
+		cfg.Style = "silence_heavy"
+		cfg.Color = "dark_grey"
+		cfg.VY = 15.0
+		cfg.Scale = 1.5
+		return "沈黙", cfg, true
+	} else if duration > 12.0 && b.SilenceStage == 3 {
+		b.SilenceStage = 4
+		cfg := NewWordConfig("静寂")
+		cfg.Style = "silence_abyss"
+		cfg.Color = "black"
+		cfg.VY = -1.0
+		cfg.Scale = 2.0
+		return "静寂", cfg, true
+	} else if duration > 17.0 && b.SilenceStage == 4 {
+		// Loop
+		b.LastSpeechTime = b.Now().Add(-12 * time.Second) // Set back to 12s mark
+		cfg := NewWordConfig("...")
+		cfg.Style = "silence_dots"
+		cfg.Color = "grey_alpha"
+		cfg.Scale = 1.0
+		return "...", cfg, true
+	}
+
+	return "", WordConfig{}, false
+}
+
+func (b *Brain) Reset() {
+	b.Tension = 0
+	b.Alignment = 0
+	b.LastSpeechTime = b.Now()
+	b.SilenceStage = 0
+}
+
+// SkipTime moves the Brain's clocks forward by d, as if d never elapsed.
+// Used on resume so a pause neither decays tension nor advances silence.
+func (b *Brain) SkipTime(d time.Duration) {
+	b.LastUpdate = b.LastUpdate.Add(d)
+	b.LastSpeechTime = b.LastSpeechTime.Add(d)
+	b.LastTurn = b.LastTurn.Add(d)
+	b.TurnStart = b.TurnStart.Add(d)
+	b.LastImpact = b.LastImpact.Add(d)
+	b.SilenceFrom = b.SilenceFrom.Add(d)
+	b.ResolvingUntil = b.ResolvingUntil.Add(d)
+}
+
+func (b *Brain) Recalculate() {
+	now := b.Now()
+	dt := now.Sub(b.LastUpdate).Seconds()
+	b.LastUpdate = now
+
+	b.decay(dt)
+}
+
+// decay cools tension down over dt seconds.
+func (b *Brain) decay(dt float64) {
+	rate := b.Config.DecayPerSec
+	if b.Now().Before(b.ResolvingUntil) {
+		rate *= b.Config.ResolveDecay
+	}
+	b.Tension -= dt * rate
+	if b.Tension < 0 {
+		b.Tension = 0
+	}
+
+	fade := b.Config.AlignFade * (1 + b.Tension/b.Config.SplitThreshold)
+	b.Alignment = max(0, b.Alignment-dt*fade)
+}
+
+// brainSnapshot is the on-disk form of the Brain's mood.
+type brainSnapshot struct {
+	Tension     float64   `json:"tension"`
+	RecentWords []string  `json:"recent_words"`
+	SavedAt     time.Time `json:"saved_at"`
+}
+
+// Save writes tension and recent words to path as JSON.
+func (b *Brain) Save(path string) error {
+	b.Recalculate()
+	data, err := json.MarshalIndent(brainSnapshot{
+		Tension:     b.Tension,
+		RecentWords: b.RecentWords,
+		SavedAt:     b.Now(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Load restores a snapshot written by Save, decaying tension for the
+// time the piece was offline so it resumes at a plausible mood.
+func (b *Brain) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var snap brainSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+
+	b.Tension = snap.Tension
+	b.RecentWords = snap.RecentWords
+	if offline := b.Now().Sub(snap.SavedAt).Seconds(); offline > 0 {
+		b.decay(offline)
+	}
+	b.LastUpdate = b.Now()
+	return nil
+}
+
+func (b *Brain) GetState() string {
+	b.Recalculate()
+
+	next := b.classify()
+	if next != b.State {
+		change := StateChange{From: b.State, To: next, At: b.Now()}
+		b.State = next
+		if b.OnStateChange != nil {
+			b.OnStateChange(change)
+		}
+	}
+
+	// A long calm after a climax resolves it
+	if b.State == "SPLIT" {
+		b.Unresolved = true
+	} else if b.Unresolved && b.Config.ResolveCalm > 0 &&
+		b.Now().Sub(b.LastSpeechTime).Seconds() > b.Config.ResolveCalm {
+		b.Resolve()
+	}
+	return b.State
+}
+
+// classify maps tension to a state with hysteresis: rising uses the
+// enter thresholds, but a state is only left once tension falls below
+// its (lower) exit threshold, so hovering at a line doesn't strobe.
+func (b *Brain) classify() string {
+	split := b.Config.SplitThreshold
+	aligned := b.Config.AlignedThreshold
+	switch b.State {
+	case "SPLIT":
+		split = b.Config.SplitExit
+		aligned = b.Config.AlignedExit
+	case "ALIGNED":
+		aligned = b.Config.AlignedExit
+	}
+
+	if b.Tension > split {
+		return "SPLIT"
+	} else if b.Tension > aligned {
+		return "ALIGNED"
+	}
+	return "UNKNOWN"
+}
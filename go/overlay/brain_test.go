@@ -0,0 +1,160 @@
+package overlay
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestBrain builds a Brain with default pacing on a fake clock.
+func newTestBrain() (*Brain, *fakeClock) {
+	clock := newFakeClock()
+	return NewBrainWithClock(DefaultBrainConfig(), clock.now), clock
+}
+
+// TestStateHysteresis drives tension back and forth across the enter
+// thresholds without reaching the exits: each state is entered once and
+// held, instead of flapping on every crossing.
+func TestStateHysteresis(t *testing.T) {
+	b, clock := newTestBrain()
+	b.Config.DecayPerSec = 0
+	var changes []StateChange
+	b.OnStateChange = func(c StateChange) { changes = append(changes, c) }
+
+	c := b.Config
+	steps := []struct {
+		tension float64
+		want    string
+	}{
+		{c.AlignedThreshold + 0.1, "ALIGNED"},
+		{c.AlignedThreshold - 0.1, "ALIGNED"}, // above AlignedExit
+		{c.AlignedThreshold + 0.1, "ALIGNED"},
+		{c.AlignedExit + 0.05, "ALIGNED"},
+		{c.AlignedThreshold + 0.1, "ALIGNED"},
+		{c.SplitThreshold + 0.1, "SPLIT"},
+		{c.SplitThreshold - 0.1, "SPLIT"}, // above SplitExit
+		{c.SplitThreshold + 0.1, "SPLIT"},
+		{c.SplitExit + 0.05, "SPLIT"},
+		{c.SplitThreshold + 0.1, "SPLIT"},
+		{c.SplitExit - 0.1, "ALIGNED"},
+		{c.AlignedExit - 0.1, "UNKNOWN"},
+		{c.AlignedThreshold - 0.1, "UNKNOWN"}, // below the enter threshold
+	}
+	for i, s := range steps {
+		clock.advance(100 * time.Millisecond)
+		b.Tension = s.tension
+		if got := b.GetState(); got != s.want {
+			t.Fatalf("step %d: tension %.2f in %s, want %s", i, s.tension, got, s.want)
+		}
+	}
+
+	want := [][2]string{
+		{"UNKNOWN", "ALIGNED"},
+		{"ALIGNED", "SPLIT"},
+		{"SPLIT", "ALIGNED"},
+		{"ALIGNED", "UNKNOWN"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d state changes %v, want %d", len(changes), changes, len(want))
+	}
+	for i, c := range changes {
+		if c.From != want[i][0] || c.To != want[i][1] {
+			t.Errorf("change %d: %s -> %s, want %s -> %s", i, c.From, c.To, want[i][0], want[i][1])
+		}
+	}
+	if at := changes[1].At; !at.Equal(newFakeClock().t.Add(600 * time.Millisecond)) {
+		t.Errorf("SPLIT change stamped %v, want the clock at the crossing", at)
+	}
+}
+
+// TestStateChangeOnce checks GetState only reports a change once, no
+// matter how often it is polled.
+func TestStateChangeOnce(t *testing.T) {
+	b, clock := newTestBrain()
+	b.Config.DecayPerSec = 0
+	n := 0
+	b.OnStateChange = func(StateChange) { n++ }
+
+	b.Tension = b.Config.SplitThreshold + 1
+	for range 60 {
+		clock.advance(time.Second / 60)
+		b.GetState()
+	}
+	if n != 1 {
+		t.Errorf("%d state changes for one crossing, want 1", n)
+	}
+}
+
+// TestImpactCooldown checks a burst of danger words flashes once while
+// still heating up, and impacts spaced past the cooldown each flash.
+func TestImpactCooldown(t *testing.T) {
+	words := []string{"嘘だ", "絶対", "違う", "おかしい"}
+
+	b, clock := newTestBrain()
+	for i, w := range words {
+		before := b.Tension
+		cfg := b.ProcessText(w)
+		if first := i == 0; cfg.Flash != first || (cfg.Shake > 0) != first {
+			t.Errorf("burst word %d %q: flash %v shake %.0f", i, w, cfg.Flash, cfg.Shake)
+		}
+		if cfg.Style != "impact" || b.Tension <= before {
+			t.Errorf("burst word %d %q: style %s, tension %.2f -> %.2f", i, w, cfg.Style, before, b.Tension)
+		}
+		clock.advance(300 * time.Millisecond)
+	}
+
+	b, clock = newTestBrain()
+	gap := time.Duration((b.Config.ImpactCooldown + 0.1) * float64(time.Second))
+	for i, w := range words {
+		if cfg := b.ProcessText(w); !cfg.Flash || cfg.Shake != b.Config.ImpactShake {
+			t.Errorf("spaced word %d %q: flash %v shake %.0f", i, w, cfg.Flash, cfg.Shake)
+		}
+		clock.advance(gap)
+	}
+
+	// A muted impact doesn't restart the window
+	b, clock = newTestBrain()
+	for i := range 5 {
+		cfg := b.ProcessText("嘘")
+		if want := i%2 == 0; cfg.Flash != want {
+			t.Errorf("impact at %.1fs: flash %v, want %v", float64(i)*0.9, cfg.Flash, want)
+		}
+		clock.advance(900 * time.Millisecond)
+	}
+}
+
+// TestAgreementResolvesConflictOnly checks agreement words only resolve
+// with tension in the air or a SPLIT left open, not in a calm room.
+func TestAgreementResolvesConflictOnly(t *testing.T) {
+	b, clock := newTestBrain()
+	resolved := 0
+	b.OnResolve = func() { resolved++ }
+
+	for range 3 {
+		b.ProcessText("なるほど")
+		clock.advance(time.Second)
+	}
+	if resolved != 0 {
+		t.Errorf("calm agreement resolved %d times", resolved)
+	}
+	if b.Alignment == 0 {
+		t.Error("calm agreement built no alignment")
+	}
+
+	b.Tension = b.Config.AlignedThreshold + 1
+	b.ProcessText("なるほど")
+	if resolved != 1 {
+		t.Errorf("agreement under tension resolved %d times, want 1", resolved)
+	}
+
+	// A SPLIT that has cooled off is still open until resolved
+	b, _ = newTestBrain()
+	b.OnResolve = func() { resolved++ }
+	resolved = 0
+	b.Tension = b.Config.SplitThreshold + 1
+	b.GetState()
+	b.Tension = 0
+	b.ProcessText("確かに")
+	if resolved != 1 || b.Unresolved {
+		t.Errorf("agreement after a SPLIT: resolved %d, unresolved %v", resolved, b.Unresolved)
+	}
+}
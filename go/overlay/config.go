@@ -0,0 +1,532 @@
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+)
+
+// Config holds the tunable knobs of the renderer.
+// A JSON file passed via -config is layered on top of DefaultConfig,
+// so it only needs the keys that differ.
+type Config struct {
+	Brain BrainConfig `json:"brain"`
+
+	// Timing
+	Width  int `json:"width"` // logical resolution
+	Height int `json:"height"`
+	TPS    int `json:"tps"`     // simulation ticks per second (physics is tuned at 60)
+	FPSCap int `json:"fps_cap"` // max redraws per second, 0 = every frame
+
+	// Grow the logical size along one side to the window's shape instead
+	// of boxing it, so ultrawides and projectors get the whole screen
+	// (off while recording frames, which need a fixed size)
+	FitWindow bool `json:"fit_window"`
+
+	// Audio -> Visuals
+	VolumeGain    float64 `json:"volume_gain"`     // RMS multiplier before smoothing
+	VolumeDB      bool    `json:"volume_db"`       // map dB instead of linear RMS (ignores volume_gain)
+	VolumeDBFloor float64 `json:"volume_db_floor"` // dB mapped to 0
+	VolumeDBCeil  float64 `json:"volume_db_ceil"`  // dB mapped to 1
+	// Level smoothing, per tick at 60 TPS. Attack is the fraction of a
+	// rise taken at once (1 = instant, lower = softer swells); decay is
+	// what remains after a tick of falling level (lower = snappier,
+	// closer to 1 = lingering glow). Idle decay applies when no reading
+	// arrived that tick
+	VolumeAttack    float64 `json:"volume_attack"`
+	VolumeDecay     float64 `json:"volume_decay"`
+	VolumeIdleDecay float64 `json:"volume_idle_decay"`
+
+	NuanceGain     float64 `json:"nuance_gain"`      // micVolume -> extra word scale
+	NuanceScaleMax float64 `json:"nuance_scale_max"` // cap on the volume-driven scale
+
+	// Shouting: words spawned with micVolume past ShoutThreshold are
+	// stretched taller, up to 1+ShoutStretch at full volume (0 = off)
+	ShoutThreshold float64 `json:"shout_threshold"`
+	ShoutStretch   float64 `json:"shout_stretch"`
+
+	// Background geometry: "split" (rotating line), "scope" (oscilloscope)
+	// or "spectrum" (radial bars)
+	GeometryMode   string  `json:"geometry_mode"`
+	ScopeAmplitude float64 `json:"scope_amplitude"` // full-scale sample as a fraction of half the screen
+	ScopeColor     string  `json:"scope_color"`
+	SpectrumBars   int     `json:"spectrum_bars"`
+	SpectrumSpin   float64 `json:"spectrum_spin"` // rad/s
+
+	// SPLIT background (color name) and its brightness at strength 1,
+	// rising to full at strength_max
+	SplitColor    string  `json:"split_color"`
+	SplitColorDim float64 `json:"split_color_dim"`
+
+	// Cap on state strength (SPLIT intensity from tension past the threshold)
+	StrengthMax float64 `json:"strength_max"`
+
+	// Split line geometry
+	GeomLines         int     `json:"geom_lines"`          // radial lines through the center
+	GeomThickness     float64 `json:"geom_thickness"`      // px at silence
+	GeomThicknessPeak float64 `json:"geom_thickness_peak"` // extra px at full mid band
+	GeomSpin          float64 `json:"geom_spin"`           // base rotation per tick
+	GeomSplitOffset   float64 `json:"geom_split_offset"`   // px between the doubled SPLIT lines
+
+	// SPLIT doubles per line: GeomSplitDoubles, plus GeomSplitGrowth more
+	// per unit of strength past 1, each turned GeomSplitFan radians
+	// (times strength) further than the last
+	GeomSplitDoubles int     `json:"geom_split_doubles"`
+	GeomSplitGrowth  float64 `json:"geom_split_growth"`
+	GeomSplitFan     float64 `json:"geom_split_fan"`
+
+	// Geometry band reaction
+	BandSmoothing [3]float64 `json:"band_smoothing"` // bass/mid/treble, 0 = raw, ->1 = sluggish
+	TrebleSpin    float64    `json:"treble_spin"`    // extra rotation per tick at full treble
+
+	// SPLIT letterbox
+	LetterboxHeight float64 `json:"letterbox_height"` // px per bar when fully in
+	LetterboxSpeed  float64 `json:"letterbox_speed"`  // fraction of the gap closed per tick
+
+	// Typewriter reveal for long phrases
+	TypewriterMinRunes int     `json:"typewriter_min_runes"` // 0 = only the "typewriter" style
+	TypewriterSpeed    float64 `json:"typewriter_speed"`     // runes per second
+
+	// Long phrase splitting
+	SplitMaxRunes int     `json:"split_max_runes"` // 0 = spawn phrases whole
+	SplitStagger  float64 `json:"split_stagger"`   // seconds between chunks
+
+	// Spoken-word rate limit (0 = off): a bucket of spawn_burst words
+	// refilled at spawn_rate per second. Impacts, conjunctions,
+	// highlights and inversions are never dropped
+	SpawnRate  float64 `json:"spawn_rate"`
+	SpawnBurst float64 `json:"spawn_burst"`
+
+	// Rolling transcript of recognized lines (toggle with F9), in a
+	// corner: top_left, top_right, bottom_left or bottom_right
+	Transcript      bool   `json:"transcript"`
+	TranscriptLines int    `json:"transcript_lines"`
+	TranscriptPos   string `json:"transcript_pos"`
+
+	// Romaji line under each word (kana only, kanji is skipped)
+	ShowRomaji bool `json:"show_romaji"`
+
+	// Extra or redefined color names -> "#RRGGBB" / "#RRGGBBAA", checked
+	// before the built-in names wherever a color name is accepted
+	Colors map[string]string `json:"colors"`
+
+	// Keyword -> forced color/scale, checked before the semantic rules
+	Highlights map[string]Highlight `json:"highlights"`
+
+	// Impact particle burst
+	ParticleCount int     `json:"particle_count"` // per impact word, 0 = off
+	ParticleSpeed float64 `json:"particle_speed"`
+	ParticleColor string  `json:"particle_color"` // color name, e.g. "red"
+	MaxParticles  int     `json:"max_particles"`
+
+	// SPLIT shockwave rings
+	ShockwaveRings int     `json:"shockwave_rings"` // 0 = off
+	ShockwaveSpeed float64 `json:"shockwave_speed"` // px per tick
+	ShockwaveColor string  `json:"shockwave_color"` // color name, white if unknown
+
+	// Glitch / SPLIT word jitter, px peak-to-peak on x and y at strength 1
+	// ([n, 0] = horizontal only)
+	GlitchJitter [2]float64 `json:"glitch_jitter"`
+
+	// SPLIT datamosh slices (off by default: flashing content)
+	GlitchBlocks bool    `json:"glitch_blocks"`
+	GlitchRate   float64 `json:"glitch_rate"` // slices per tick at tension 10
+
+	// "上下反転" flips the whole world
+	InvertWorld    bool    `json:"invert_world"`
+	InvertDuration float64 `json:"invert_duration"` // seconds, momentary mode
+	InvertLatched  bool    `json:"invert_latched"`  // toggle until spoken again
+
+	// "左右反転" mirrors the whole world (speaker sides swap with it)
+	MirrorWorld    bool    `json:"mirror_world"`
+	MirrorDuration float64 `json:"mirror_duration"` // seconds, momentary mode
+	MirrorLatched  bool    `json:"mirror_latched"`
+	MirrorGlyphs   bool    `json:"mirror_glyphs"` // false keeps words readable
+
+	// "色反転" inverts the frame colors
+	ColorInvert         bool    `json:"color_invert"`
+	ColorInvertDuration float64 `json:"color_invert_duration"` // seconds
+	ColorInvertPulse    bool    `json:"color_invert_pulse"`
+	ColorInvertCPU      bool    `json:"color_invert_cpu"` // low-end GPUs: invert bg and words only
+
+	// Fonts: the first of FontPaths that loads wins (relative to AssetsDir)
+	AssetsDir   string   `json:"assets_dir"`
+	FontPaths   []string `json:"font_paths"`
+	FontSize    float64  `json:"font_size"`     // small face (romaji, type buffer)
+	FontSizeBig float64  `json:"font_size_big"` // barrage words at scale 1
+
+	// Background kanji motif; F6 cycles through the list and off
+	Watermarks      []string `json:"watermarks"`
+	WatermarkSize   float64  `json:"watermark_size"` // font px
+	WatermarkAlpha  float64  `json:"watermark_alpha"`
+	WatermarkSpin   float64  `json:"watermark_spin"`   // rad/s
+	WatermarkBreath float64  `json:"watermark_breath"` // extra scale at full mic volume
+
+	// High-contrast monochrome: white on black, red as the only accent
+	Monochrome bool `json:"monochrome"`
+
+	// Overall opacity of the frame and its overlays, 0.1..1. This only
+	// fades the frame; showing the desktop through it also needs a
+	// transparent window.
+	Opacity float64 `json:"opacity"`
+
+	// Film grain (0 = off)
+	GrainIntensity float64 `json:"grain_intensity"` // overlay alpha when calm
+	GrainTension   float64 `json:"grain_tension"`   // extra intensity (x) at split-level tension
+
+	// Vignette (0 = off)
+	VignetteStrength   float64 `json:"vignette_strength"` // edge opacity, 0..1
+	VignetteColor      string  `json:"vignette_color"`    // color name
+	VignetteSplitPulse bool    `json:"vignette_split_pulse"`
+
+	// Persist the Brain's mood across restarts ("" = off)
+	BrainStatePath string `json:"brain_state_path"`
+
+	// Audio input: "mic", or "loopback" to react to system audio (what
+	// the speakers play). Loopback needs WASAPI, so Windows only; other
+	// platforms log it and use the mic. Recognition false skips Vosk and
+	// only the levels drive the piece, e.g. for music
+	AudioSource string `json:"audio_source"`
+	Recognition bool   `json:"recognition"`
+
+	// Restrict recognition to these phrases (empty = free recognition)
+	Vocabulary []string `json:"vocabulary"`
+
+	// Recognition confidence gate (0 = off). Below it a result is
+	// dropped, or with low_confidence "ghost" shown faint
+	MinConfidence float64 `json:"min_confidence"`
+	LowConfidence string  `json:"low_confidence"` // "drop" or "ghost"
+
+	// A result identical to the previous one (same speaker) within this
+	// many seconds is an endpointing double and is dropped (0 = off)
+	DedupeWindow float64 `json:"dedupe_window"`
+
+	// Turn assignment: "gap" (flip after a 2s pause or a conjunction) or
+	// "energy" (by the level over each utterance; see speakerFromEnergy)
+	TurnStrategy     string  `json:"turn_strategy"`
+	TurnEnergyMargin float64 `json:"turn_energy_margin"` // level ratio that marks a new voice (mono)
+
+	// Two mics panned L/R: the louder channel picks the speaker
+	StereoInput  bool    `json:"stereo_input"`
+	StereoMargin float64 `json:"stereo_margin"` // louder side must exceed the other by this ratio
+
+	// Discord voice bridge (-discord; needs a -tags discord build). The
+	// bot joins the channel muted and recognizes each user separately
+	DiscordToken   string `json:"discord_token"`
+	DiscordGuild   string `json:"discord_guild"`
+	DiscordChannel string `json:"discord_channel"`
+
+	// Wake word gating ("" = always listening)
+	WakeWord   string  `json:"wake_word"`
+	WakeWindow float64 `json:"wake_window"` // seconds active after the wake word
+
+	// Log level: debug / info / warn / error
+	LogLevel string `json:"log_level"`
+
+	// Prometheus endpoint, e.g. ":9100" serves /metrics ("" = off)
+	MetricsAddr string `json:"metrics_addr"`
+
+	// Corner readout, toggled with F3; off by default for clean captures.
+	// Fields: volume, state, tension, alignment, barrage, fps, dropped
+	ShowDebug   bool     `json:"show_debug"`
+	DebugFields []string `json:"debug_fields"`
+
+	// Shatter: short impact words burst into runes and reassemble
+	ShatterChance   float64 `json:"shatter_chance"` // per eligible impact word
+	ShatterMaxRunes int     `json:"shatter_max_runes"`
+	ShatterSpeed    float64 `json:"shatter_speed"`   // scatter velocity spread
+	ShatterScatter  float64 `json:"shatter_scatter"` // seconds before reassembly
+
+	// Where words pile: "floor" (floor_height px above the bottom),
+	// "edge" (the bottom edge itself) or "none" (they fall off and die)
+	FloorMode   string  `json:"floor_mode"`
+	FloorHeight float64 `json:"floor_height"`
+
+	// Agreement visuals, following Brain.Alignment: words drift toward the
+	// center line, the background washes toward AlignColor and the
+	// geometry stops reacting to the audio
+	AlignPull  float64 `json:"align_pull"` // 0 = off
+	AlignColor string  `json:"align_color"`
+
+	// Style -> spawn physics, merged over the built-ins (an entry
+	// replaces the built-in one for that style as a whole)
+	Styles map[string]StylePhysics `json:"styles"`
+
+	// Physics
+	Launch       LaunchConfig `json:"launch"`
+	StickyChance float64      `json:"sticky_chance"` // probability a word clings to the side walls
+
+	// Gravity wells (WellStrength 0 = off)
+	WellStrength      float64 `json:"well_strength"`
+	WellRadius        float64 `json:"well_radius"`
+	WellAtSpeaker     bool    `json:"well_at_speaker"`      // pull toward the active speaker
+	WellAtSplitCenter bool    `json:"well_at_split_center"` // vortex at the center during SPLIT
+
+	// Orbit: during ALIGNED up to OrbitMax new words circle the center
+	OrbitMax   int     `json:"orbit_max"`   // 0 = off
+	OrbitSpeed float64 `json:"orbit_speed"` // rad/s
+
+	// Integrate large barrages on several goroutines (0/1 = serial)
+	PhysicsWorkers     int `json:"physics_workers"`
+	PhysicsParallelMin int `json:"physics_parallel_min"` // words before going parallel
+
+	// Spatial grid cell for area effects, px (~ a couple of word widths)
+	GridCell float64 `json:"grid_cell"`
+
+	// NDI video feed for VJ rigs ("" = off; needs a -tags ndi build)
+	NDIName   string `json:"ndi_name"`
+	NDIWidth  int    `json:"ndi_width"` // 0 = logical resolution
+	NDIHeight int    `json:"ndi_height"`
+	NDIFPS    int    `json:"ndi_fps"` // 0 = TPS
+
+	// Virtual camera (-vcam or vcam_device, e.g. a v4l2loopback /dev/video10)
+	VCamDevice string `json:"vcam_device"`
+	VCamWidth  int    `json:"vcam_width"`
+	VCamHeight int    `json:"vcam_height"`
+	VCamFPS    int    `json:"vcam_fps"`
+
+	// -record-frames: keep every Nth rendered frame, up to a cap
+	RecordEvery     int `json:"record_every"`
+	RecordMaxFrames int `json:"record_max_frames"` // 0 = unlimited
+
+	// Re-apply the -config file when it is saved
+	WatchConfig bool `json:"watch_config"`
+
+	// Named looks: Ctrl+1..9 switch to the preset at that index, Ctrl+0
+	// returns to the startup config. Each file is layered over the
+	// startup config (relative to AssetsDir).
+	Presets    []string `json:"presets"`
+	PresetFade float64  `json:"preset_fade"` // seconds to blend numeric knobs, 0 = snap
+
+	// Mouse / touch shove (off for pure live capture)
+	MouseShove     bool    `json:"mouse_shove"`
+	MouseRadius    float64 `json:"mouse_radius"`
+	MouseForce     float64 `json:"mouse_force"`
+	MouseHeldBoost float64 `json:"mouse_held_boost"` // force multiplier while pressed / touching
+}
+
+// StylePhysics is a style's spawn look and motion. Fields left zero keep
+// the defaults (volume-based scale, 600 ticks of life, white, the usual
+// tilt and gravity). A style with an Origin is placed there and moves at
+// VX/VY plus a centered random Spread; without one it is thrown like
+// ordinary speech. The Brain's per-word overrides apply on top.
+type StylePhysics struct {
+	Scale     float64    `json:"scale"`      // absolute scale
+	ScaleMult float64    `json:"scale_mult"` // times the default scale
+	Life      int        `json:"life"`       // ticks at 60 TPS
+	Color     string     `json:"color"`
+	Origin    string     `json:"origin"` // erupt, anywhere, speaker, middle, top, bottom
+	VX        float64    `json:"vx"`
+	VY        float64    `json:"vy"`
+	Spread    [2]float64 `json:"spread"`
+	Gravity   float64    `json:"gravity"` // factor on the world gravity
+	Still     bool       `json:"still"`   // no initial tilt or spin
+}
+
+// styleOrigins are the accepted StylePhysics.Origin values.
+var styleOrigins = []string{"", "erupt", "anywhere", "speaker", "middle", "top", "bottom"}
+
+// defaultStyles is the built-in style table.
+func defaultStyles() map[string]StylePhysics {
+	impact := StylePhysics{ScaleMult: 1.5, Life: 300, Color: "red", Origin: "erupt", Spread: [2]float64{10, 10}}
+	return map[string]StylePhysics{
+		"impact":        impact,
+		"glitch":        impact,
+		"silence_dots":  {Scale: 1, Life: 300, Color: "grey_alpha", Origin: "anywhere", Spread: [2]float64{0.5, 0.5}},
+		"thinking":      {Scale: 1.2, Life: 400, Origin: "speaker", VY: -0.4, Spread: [2]float64{0.4, 0}, Still: true},
+		"silence_ma":    {Scale: 3, Life: 800, Color: "blue_white", Origin: "middle"},
+		"silence_heavy": {Scale: 5, Life: 1000, Color: "dark_grey", Origin: "top", VY: 15},
+		"silence_abyss": {Scale: 7, Life: 1200, Color: "black", Origin: "bottom", VY: -1},
+	}
+}
+
+// LaunchConfig is the throw of a normal word from its speaker's side.
+// Speaker 1 mirrors VX; VY is negative for upward.
+type LaunchConfig struct {
+	VX     float64 `json:"vx"`      // toward the other speaker
+	VXRand float64 `json:"vx_rand"` // added at random on top of VX
+	VY     float64 `json:"vy"`
+	VYRand float64 `json:"vy_rand"` // extra upward at random
+
+	// Landscape spawn height per speaker, as a fraction of the screen height
+	SpeakerY [2]float64 `json:"speaker_y"`
+
+	// SPLIT and glitch words: velocity multiplier at Strength 1 (scaled
+	// with Strength) and the width of the center eruption in px
+	SplitBoost  float64 `json:"split_boost"`
+	EruptSpread float64 `json:"erupt_spread"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Brain:               DefaultBrainConfig(),
+		TPS:                 60,
+		VolumeGain:          8.0,
+		VolumeAttack:        1,
+		VolumeDecay:         0.92,
+		VolumeIdleDecay:     0.95,
+		NuanceGain:          3.0,
+		NuanceScaleMax:      4.0,
+		ShoutThreshold:      0.7,
+		ShoutStretch:        0.5,
+		BandSmoothing:       [3]float64{0.85, 0.7, 0.5},
+		TrebleSpin:          0.2,
+		LetterboxHeight:     140,
+		LetterboxSpeed:      0.08,
+		TypewriterSpeed:     12,
+		WellRadius:          600,
+		MouseRadius:         250,
+		MouseForce:          2.0,
+		MouseHeldBoost:      3.0,
+		SplitStagger:        0.15,
+		SpawnBurst:          6,
+		ParticleCount:       24,
+		ParticleSpeed:       12,
+		ParticleColor:       "red",
+		MaxParticles:        400,
+		ShockwaveRings:      3,
+		ShockwaveSpeed:      18,
+		GlitchRate:          0.3,
+		GlitchJitter:        [2]float64{10, 10},
+		InvertDuration:      5,
+		MirrorDuration:      5,
+		ColorInvertDuration: 3,
+		ShatterMaxRunes:     3,
+		ShatterSpeed:        30,
+		ShatterScatter:      0.6,
+		OrbitSpeed:          0.4,
+		LogLevel:            "info",
+		DebugFields:         []string{"volume", "state", "fps", "dropped"},
+		WakeWindow:          30,
+		StereoMargin:        1.3,
+		Styles:              defaultStyles(),
+		AlignPull:           0.002,
+		AlignColor:          "#0a193cff",
+		TurnStrategy:        "gap",
+		TurnEnergyMargin:    1.5,
+		VolumeDBFloor:       -50,
+		VolumeDBCeil:        -10,
+		FloorMode:           "floor",
+		FloorHeight:         100,
+		Launch:              LaunchConfig{VX: 5, VXRand: 5, VY: -5, VYRand: 5, SpeakerY: [2]float64{0.4, 0.4}, SplitBoost: 2, EruptSpread: 400},
+		GrainTension:        1.5,
+		VignetteColor:       "black",
+		WatermarkSize:       720,
+		WatermarkAlpha:      0.06,
+		WatermarkSpin:       0.02,
+		WatermarkBreath:     0.15,
+		GeometryMode:        "split",
+		ScopeAmplitude:      2,
+		ScopeColor:          "cyan",
+		SpectrumBars:        48,
+		SpectrumSpin:        0.1,
+		GeomLines:           1,
+		GeomThickness:       2,
+		GeomThicknessPeak:   10,
+		GeomSpin:            0.02,
+		GeomSplitOffset:     20,
+		GeomSplitDoubles:    1,
+		Opacity:             1,
+		StrengthMax:         3,
+		SplitColor:          "red",
+		SplitColorDim:       0.6,
+		GridCell:            200,
+		PhysicsParallelMin:  512,
+		AssetsDir:           "assets",
+		FontPaths:           []string{"font.otf", `C:\Windows\Fonts\meiryo.ttc`},
+		FontSize:            24,
+		FontSizeBig:         72,
+		Width:               1920,
+		Height:              1080,
+		Presets:             []string{"presets/calm.json", "presets/storm.json", "presets/print.json"},
+		PresetFade:          2,
+		WatchConfig:         true,
+		RecordEvery:         1,
+		VCamWidth:           1280,
+		VCamHeight:          720,
+		VCamFPS:             30,
+		RecordMaxFrames:     18000, // 5 minutes at 60 fps
+		LowConfidence:       "drop",
+		DedupeWindow:        0.5,
+		TranscriptLines:     6,
+		TranscriptPos:       "bottom_left",
+		AudioSource:         "mic",
+		Recognition:         true,
+	}
+}
+
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	return loadConfigOver(DefaultConfig(), path)
+}
+
+// loadConfigOver layers the JSON file at path on top of base.
+// On any error base is returned unchanged.
+func loadConfigOver(base Config, path string) (Config, error) {
+	cfg := base.clone() // Unmarshal writes into maps and slices in place
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return base, err
+	}
+	if cfg.TPS <= 0 {
+		cfg.TPS = 60
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return base, fmt.Errorf("need a positive width and height, got %dx%d", cfg.Width, cfg.Height)
+	}
+	cfg.Opacity = math.Max(0.1, math.Min(1, cfg.Opacity))
+	cfg.VolumeAttack = math.Max(0.01, math.Min(1, cfg.VolumeAttack))
+	if cfg.VolumeDecay < 0 || cfg.VolumeDecay >= 1 || cfg.VolumeIdleDecay < 0 || cfg.VolumeIdleDecay >= 1 {
+		return base, fmt.Errorf("need 0 <= volume_decay, volume_idle_decay < 1")
+	}
+	if cfg.VolumeDBCeil <= cfg.VolumeDBFloor {
+		return base, fmt.Errorf("need volume_db_floor (%.1f) < volume_db_ceil (%.1f)",
+			cfg.VolumeDBFloor, cfg.VolumeDBCeil)
+	}
+	if cfg.AudioSource != "mic" && cfg.AudioSource != "loopback" {
+		return base, fmt.Errorf("audio_source must be \"mic\" or \"loopback\", got %q", cfg.AudioSource)
+	}
+	for style, spec := range cfg.Styles {
+		if !slices.Contains(styleOrigins, spec.Origin) {
+			return base, fmt.Errorf("styles[%q]: unknown origin %q", style, spec.Origin)
+		}
+	}
+	for name, hex := range cfg.Colors {
+		if _, err := parseHexColor(hex); err != nil {
+			return base, fmt.Errorf("colors[%q]: %w", name, err)
+		}
+	}
+	if err := cfg.Brain.Validate(); err != nil {
+		return base, err
+	}
+	return cfg, nil
+}
+
+// clone copies c with its own maps and slices, so decoding over the
+// copy never reaches back into c.
+func (c Config) clone() Config {
+	c.Colors = maps.Clone(c.Colors)
+	c.Highlights = maps.Clone(c.Highlights)
+	c.Styles = maps.Clone(c.Styles)
+	c.FontPaths = slices.Clone(c.FontPaths)
+	c.Watermarks = slices.Clone(c.Watermarks)
+	c.Vocabulary = slices.Clone(c.Vocabulary)
+	c.DebugFields = slices.Clone(c.DebugFields)
+	c.Presets = slices.Clone(c.Presets)
+	c.Brain.DangerWords = maps.Clone(c.Brain.DangerWords)
+	c.Brain.ResolveWords = slices.Clone(c.Brain.ResolveWords)
+	return c
+}
+
+func (c Config) LogEffective() {
+	logInfof("Config: tps=%d fps_cap=%d volume_gain=%.2f nuance_gain=%.2f nuance_scale_max=%.2f",
+		c.TPS, c.FPSCap, c.VolumeGain, c.NuanceGain, c.NuanceScaleMax)
+}
@@ -0,0 +1,98 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a JSON config to a temp file and returns its path.
+func writeConfig(t *testing.T, js string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "preset.json")
+	if err := os.WriteFile(path, []byte(js), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const overridingPreset = `{
+	"colors": {"grey": "#102030ff"},
+	"styles": {"impact": {"scale": 9, "origin": "middle"}},
+	"debug_fields": ["tension"],
+	"fps_cap": 15,
+	"brain": {"danger_words": {"嘘": 9}, "resolve_words": ["はい"]}
+}`
+
+func TestLoadConfigOverLeavesBaseAlone(t *testing.T) {
+	base := DefaultConfig()
+	base.Colors = map[string]string{"grey": "#c8c8c8ff"}
+	want := base.clone()
+
+	cfg, err := loadConfigOver(base, writeConfig(t, overridingPreset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Colors["grey"] != "#102030ff" || cfg.Styles["impact"].Scale != 9 {
+		t.Fatalf("preset not applied: colors %v, impact %+v", cfg.Colors, cfg.Styles["impact"])
+	}
+	if !reflect.DeepEqual(base, want) {
+		t.Errorf("base changed by loading a preset:\ncolors %v\nimpact %+v\ndebug %v\ndanger %v",
+			base.Colors, base.Styles["impact"], base.DebugFields, base.Brain.DangerWords)
+	}
+}
+
+func TestLoadConfigOverBadFileLeavesBaseAlone(t *testing.T) {
+	base := DefaultConfig()
+	want := base.clone()
+
+	// Decodes the styles entry, then fails on the type error
+	_, err := loadConfigOver(base, writeConfig(t, `{"styles": {"impact": {"scale": 9}}, "tps": "fast"}`))
+	if err == nil {
+		t.Fatal("want a decode error")
+	}
+	if !reflect.DeepEqual(base, want) {
+		t.Errorf("base changed by a failed load: impact %+v", base.Styles["impact"])
+	}
+}
+
+func TestPresetRestoresStartup(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Presets = []string{writeConfig(t, overridingPreset)}
+	cfg.PresetFade = 0
+	g := NewGame(cfg, 1, time.Now)
+	want := g.baseCfg.clone()
+
+	g.applyPreset(0)
+	if g.cfg.Styles["impact"].Scale != 9 {
+		t.Fatalf("preset not applied: impact %+v", g.cfg.Styles["impact"])
+	}
+	if g.cfg.FPSCap != want.FPSCap {
+		// Run only turns off screen clearing for a cap set at startup
+		t.Errorf("preset changed fps_cap to %d", g.cfg.FPSCap)
+	}
+	g.applyPreset(-1)
+	if !reflect.DeepEqual(g.baseCfg, want) {
+		t.Errorf("startup config changed by a preset: impact %+v", g.baseCfg.Styles["impact"])
+	}
+	if !reflect.DeepEqual(g.cfg, want) {
+		t.Errorf("startup look not restored: impact %+v", g.cfg.Styles["impact"])
+	}
+}
+
+func TestPresetPastTheEndIgnored(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Presets = []string{writeConfig(t, overridingPreset)}
+	cfg.PresetFade = 0
+	g := NewGame(cfg, 1, time.Now)
+
+	g.applyPreset(0)
+	for _, i := range []int{1, 8, -2} {
+		g.applyPreset(i)
+		if g.preset != 0 || g.cfg.Styles["impact"].Scale != 9 {
+			t.Errorf("preset %d: switched away from preset 0 (now %d, impact %+v)", i, g.preset, g.cfg.Styles["impact"])
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// drawDebug prints the operator readout in the top-left corner: the
+// configured DebugFields while it is on, and PAUSED / MUTED either way.
+func (g *Game) drawDebug(screen *ebiten.Image) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var parts []string
+	if g.showDebug {
+		for _, f := range g.cfg.DebugFields {
+			switch f {
+			case "volume":
+				parts = append(parts, fmt.Sprintf("Vol: %.2f", g.micVolume))
+			case "state":
+				parts = append(parts, "State: "+g.state.CurrentState)
+			case "tension":
+				parts = append(parts, fmt.Sprintf("Tension: %.1f", g.brain.Tension))
+			case "alignment":
+				parts = append(parts, fmt.Sprintf("Align: %.2f", g.alignment))
+			case "barrage":
+				parts = append(parts, fmt.Sprintf("Words: %d", len(g.barrage)))
+			case "fps":
+				parts = append(parts, fmt.Sprintf("TPS: %.0f FPS: %.0f", ebiten.ActualTPS(), ebiten.ActualFPS()))
+			case "dropped":
+				if g.speech != nil {
+					if lostVol, lostText := g.speech.DroppedVol.Load(), g.speech.DroppedText.Load(); lostVol+lostText > 0 {
+						parts = append(parts, fmt.Sprintf("Dropped: vol %d text %d", lostVol, lostText))
+					}
+				}
+			}
+		}
+	}
+	if g.paused {
+		parts = append(parts, "PAUSED")
+	}
+	if g.speech != nil && g.speech.Muted.Load() {
+		parts = append(parts, "MUTED")
+	}
+	if len(parts) > 0 {
+		ebitenutil.DebugPrint(screen, strings.Join(parts, " | "))
+	}
+}
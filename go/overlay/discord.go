@@ -0,0 +1,116 @@
+//go:build discord
+
+package overlay
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+
+	vosk "github.com/alphacep/vosk-api/go"
+	"github.com/bwmarrin/discordgo"
+	"layeh.com/gopus"
+)
+
+const (
+	discordRate     = 48000 // Discord sends 48 kHz stereo Opus
+	discordChannels = 2
+	opusMaxFrame    = 5760 // samples per channel in the longest Opus frame
+)
+
+// discordStream is one Discord user's audio: its own decoder and
+// recognizer, and the speaker slot their words are thrown from.
+type discordStream struct {
+	dec     *gopus.Decoder
+	rec     *vosk.VoskRecognizer
+	speaker int
+}
+
+// startDiscord joins the configured voice channel as a bot and feeds
+// every user's audio into se. Users take speaker slots in the order they
+// first talk (alternating sides), so a two-person call maps to L/R.
+func startDiscord(cfg Config, se *SpeechEngine) (func(), error) {
+	if se == nil {
+		return nil, fmt.Errorf("no speech engine")
+	}
+	dg, err := discordgo.New("Bot " + cfg.DiscordToken)
+	if err != nil {
+		return nil, err
+	}
+	dg.Identify.Intents = discordgo.IntentsGuildVoiceStates
+	if err := dg.Open(); err != nil {
+		return nil, err
+	}
+
+	// Muted: the bot only listens
+	vc, err := dg.ChannelVoiceJoin(cfg.DiscordGuild, cfg.DiscordChannel, true, false)
+	if err != nil {
+		dg.Close()
+		return nil, err
+	}
+	logInfo("Discord: listening in", cfg.DiscordChannel)
+
+	go se.runDiscord(vc)
+	return func() {
+		vc.Disconnect()
+		dg.Close()
+	}, nil
+}
+
+// runDiscord decodes and recognizes packets until the connection closes.
+func (se *SpeechEngine) runDiscord(vc *discordgo.VoiceConnection) {
+	streams := map[uint32]*discordStream{}
+	mono := make([]int16, opusMaxFrame)
+
+	for pkt := range vc.OpusRecv {
+		st := streams[pkt.SSRC]
+		if st == nil {
+			dec, err := gopus.NewDecoder(discordRate, discordChannels)
+			if err != nil {
+				logError("Discord Opus Error:", err)
+				continue
+			}
+			rec, err := se.newRecognizer(discordRate)
+			if err != nil {
+				logError("Discord Recognizer Error:", err)
+				continue
+			}
+			st = &discordStream{dec: dec, rec: rec, speaker: len(streams) % 2}
+			streams[pkt.SSRC] = st
+			logInfo("Discord: new voice", pkt.SSRC, "-> speaker", st.speaker)
+		}
+
+		stereo, err := st.dec.Decode(pkt.Opus, opusMaxFrame, false)
+		if err != nil {
+			logDebug("Discord Opus Error:", err)
+			continue
+		}
+		if se.Muted.Load() {
+			continue // Still decoded, so the stream stays in step
+		}
+
+		n := len(stereo) / discordChannels
+		sum := 0.0
+		for i := 0; i < n; i++ {
+			mono[i] = int16((int32(stereo[2*i]) + int32(stereo[2*i+1])) / 2)
+			v := float64(mono[i]) / 32768.0
+			sum += v * v
+		}
+		if n == 0 {
+			continue
+		}
+		se.pushWaveform(mono[:n])
+		select {
+		case se.VolChan <- math.Sqrt(sum / float64(n)):
+		default:
+			se.DroppedVol.Add(1)
+		}
+
+		pcm := unsafe.Slice((*byte)(unsafe.Pointer(&mono[0])), n*2)
+		if st.rec.AcceptWaveform(pcm) != 0 {
+			if u := parseResult(st.rec.Result(), st.speaker); u.Text != "" {
+				se.emit(u)
+			}
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !discord
+
+package overlay
+
+import "errors"
+
+func startDiscord(cfg Config, se *SpeechEngine) (func(), error) {
+	return nil, errors.New("built without Discord support (rebuild with -tags discord)")
+}
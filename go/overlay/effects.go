@@ -0,0 +1,174 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// updateLetterbox eases the cinematic bars in during SPLIT and out otherwise.
+func (g *Game) updateLetterbox() {
+	target := 0.0
+	if g.state.CurrentState == "SPLIT" {
+		target = 1.0
+	}
+	k := 1 - math.Pow(1-g.cfg.LetterboxSpeed, g.tickScale())
+	g.letterbox += (target - g.letterbox) * k
+}
+
+// drawLetterbox draws the top and bottom bars at the current slide-in amount.
+func (g *Game) drawLetterbox(screen *ebiten.Image) {
+	g.mu.RLock()
+	amount := g.letterbox
+	g.mu.RUnlock()
+
+	h := float32(amount * g.cfg.LetterboxHeight)
+	if h < 0.5 {
+		return
+	}
+	w, sh := float32(g.width), float32(g.height)
+	c := scaleAlpha(ColBlack, g.cfg.Opacity)
+	vector.DrawFilledRect(screen, 0, 0, w, h, c, false)
+	vector.DrawFilledRect(screen, 0, sh-h, w, h, c, false)
+}
+
+// typewriterClip returns the part of b's cached image revealed so far,
+// or nil if nothing is visible yet. The full image keeps its size, so
+// the clip stays anchored where the finished word will be.
+func (g *Game) typewriterClip(b *BarrageWord) *ebiten.Image {
+	runes := float64(utf8.RuneCountInString(b.Text))
+	elapsed := float64(b.MaxLife-b.Life) * g.tickScale() / 60.0
+	shown := elapsed * g.cfg.TypewriterSpeed
+	if shown >= runes {
+		return b.Image
+	}
+
+	w, h := b.Image.Size()
+	cw := int(float64(w) * math.Ceil(shown) / runes)
+	if cw <= 0 {
+		return nil
+	}
+	return b.Image.SubImage(image.Rect(0, 0, cw, h)).(*ebiten.Image)
+}
+
+// Shockwave is an expanding ring emitted when the state breaks into SPLIT.
+type Shockwave struct {
+	X, Y    float64
+	Radius  float64
+	Life    int
+	MaxLife int
+}
+
+// emitShockwaves starts ShockwaveRings rings from the center, each one
+// trailing the previous so they read as a single "break".
+func (g *Game) emitShockwaves() {
+	life := int(1.2 * 60 / g.tickScale())
+	for i := 0; i < g.cfg.ShockwaveRings; i++ {
+		g.shockwaves = append(g.shockwaves, Shockwave{
+			X: g.width / 2, Y: g.height / 2,
+			Radius: -float64(i) * 80,
+			Life:   life, MaxLife: life,
+		})
+	}
+}
+
+func (g *Game) updateShockwaves() {
+	alive := g.shockwaves[:0]
+	for _, s := range g.shockwaves {
+		s.Radius += g.cfg.ShockwaveSpeed * g.tickScale()
+		s.Life--
+		if s.Life > 0 {
+			alive = append(alive, s)
+		}
+	}
+	g.shockwaves = alive
+}
+
+// drawShockwaves is called from drawGeometry with the read lock held.
+func (g *Game) drawShockwaves(screen *ebiten.Image, dx, dy float64) {
+	col, ok := g.namedColor(g.cfg.ShockwaveColor)
+	if !ok {
+		col = ColWhite
+	}
+	col = g.monoColor(col)
+	for _, s := range g.shockwaves {
+		if s.Radius <= 0 {
+			continue
+		}
+		fade := float64(s.Life) / float64(s.MaxLife)
+		c := col
+		c.A = uint8(float64(c.A) * fade)
+		vector.StrokeCircle(screen, float32(s.X+dx), float32(s.Y+dy), float32(s.Radius), float32(2+10*fade), c, true)
+	}
+}
+
+// triggerInvertH mirrors the world on a spoken "左右反転", momentary or latched.
+func (g *Game) triggerInvertH() {
+	if !g.cfg.MirrorWorld {
+		return
+	}
+	if g.cfg.MirrorLatched {
+		g.mirrorLatched = !g.mirrorLatched
+		return
+	}
+	g.mirrorUntil = g.brain.Now().Add(time.Duration(g.cfg.MirrorDuration * float64(time.Second)))
+}
+
+// updateMirror eases mirrorX toward -1 while mirrored, like a card flip.
+func (g *Game) updateMirror() {
+	target := 1.0
+	if g.mirrorLatched || g.brain.Now().Before(g.mirrorUntil) {
+		target = -1.0
+	}
+	k := 1 - math.Pow(0.88, g.tickScale())
+	g.mirrorX += (target - g.mirrorX) * k
+	if math.Abs(target-g.mirrorX) < 0.001 {
+		g.mirrorX = target
+	}
+}
+
+// triggerInvertC starts a color inversion window on a spoken "色反転".
+func (g *Game) triggerInvertC() {
+	if !g.cfg.ColorInvert {
+		return
+	}
+	g.colorInvertUntil = g.brain.Now().Add(time.Duration(g.cfg.ColorInvertDuration * float64(time.Second)))
+}
+
+// updateColorInvert eases the inversion amount in and out, pulsing if configured.
+func (g *Game) updateColorInvert() {
+	target := 0.0
+	if g.brain.Now().Before(g.colorInvertUntil) {
+		target = 1.0
+		if g.cfg.ColorInvertPulse {
+			target = math.Abs(math.Sin(g.clock * 9))
+		}
+	}
+	k := 1 - math.Pow(0.8, g.tickScale())
+	g.colorInvert += (target - g.colorInvert) * k
+	if g.colorInvert < 0.001 {
+		g.colorInvert = 0
+	}
+}
+
+// invertColorM blends between identity and "1 - c" by amount a.
+func invertColorM(a float64) colorm.ColorM {
+	var cm colorm.ColorM
+	cm.Scale(1-2*a, 1-2*a, 1-2*a, 1)
+	cm.Translate(a, a, a, 0)
+	return cm
+}
+
+// invertRGBA is the CPU fallback for a single color.
+func invertRGBA(c color.RGBA, a float64) color.RGBA {
+	inv := func(v uint8) uint8 {
+		return uint8(float64(v) + (255-2*float64(v))*a)
+	}
+	return color.RGBA{inv(c.R), inv(c.G), inv(c.B), c.A}
+}
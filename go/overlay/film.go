@@ -0,0 +1,109 @@
+package overlay
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	grainTile = 256
+
+	// The vignette is a smooth gradient, so a small image scaled up is enough
+	vignetteDownscale = 4
+)
+
+// drawGrain tiles a noise image over the frame at a random offset each
+// frame, getting grittier as tension climbs toward SPLIT.
+func (g *Game) drawGrain(screen *ebiten.Image, paused bool) {
+	if g.cfg.GrainIntensity <= 0 {
+		return
+	}
+	g.mu.Lock()
+	if g.grain == nil {
+		g.grain = g.newGrainTile()
+	}
+	heat := math.Min(g.brain.Tension/g.cfg.Brain.SplitThreshold, 1)
+	g.mu.Unlock()
+	alpha := g.cfg.GrainIntensity * (1 + heat*g.cfg.GrainTension)
+
+	if !paused {
+		g.grainX = g.drawRng.Intn(grainTile)
+		g.grainY = g.drawRng.Intn(grainTile)
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.ColorScale.ScaleAlpha(float32(math.Min(alpha, 1) * g.cfg.Opacity))
+	for y := -g.grainY; y < int(g.height); y += grainTile {
+		for x := -g.grainX; x < int(g.width); x += grainTile {
+			op.GeoM.Reset()
+			op.GeoM.Translate(float64(x), float64(y))
+			screen.DrawImage(g.grain, op)
+		}
+	}
+}
+
+// newGrainTile builds one tile of grey noise, premultiplied alpha.
+func (g *Game) newGrainTile() *ebiten.Image {
+	pix := make([]byte, grainTile*grainTile*4)
+	for i := 0; i < len(pix); i += 4 {
+		v := byte(g.drawRng.Intn(256))
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = v, v, v, 255
+	}
+	img := ebiten.NewImage(grainTile, grainTile)
+	img.WritePixels(pix)
+	return img
+}
+
+// drawVignette darkens the frame edges with the precomputed gradient.
+// During SPLIT it can pulse toward red.
+func (g *Game) drawVignette(screen *ebiten.Image, state string) {
+	if g.cfg.VignetteStrength <= 0 {
+		return
+	}
+	g.mu.Lock()
+	if g.vignette == nil {
+		g.vignette = newVignette(int(g.width)/vignetteDownscale, int(g.height)/vignetteDownscale, g.cfg.VignetteStrength)
+	}
+	vignette := g.vignette
+	g.mu.Unlock()
+
+	c, ok := g.namedColor(g.cfg.VignetteColor)
+	if !ok {
+		c = ColBlack
+	}
+	if state == "SPLIT" && g.cfg.VignetteSplitPulse {
+		g.mu.RLock()
+		pulse := 0.5 + 0.5*math.Sin(g.clock*6)
+		g.mu.RUnlock()
+		c = lerpColor(c, ColRed, pulse)
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(vignetteDownscale, vignetteDownscale)
+	op.Filter = ebiten.FilterLinear
+	op.ColorScale.ScaleWithColor(color.RGBA{c.R, c.G, c.B, 255})
+	op.ColorScale.ScaleAlpha(float32(g.cfg.Opacity))
+	screen.DrawImage(vignette, op)
+}
+
+// newVignette builds a white radial gradient whose alpha rises from 0
+// inside the center ellipse to strength at the corners.
+func newVignette(w, h int, strength float64) *ebiten.Image {
+	pix := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			nx := (float64(x)+0.5)/float64(w)*2 - 1
+			ny := (float64(y)+0.5)/float64(h)*2 - 1
+			d := math.Min(math.Hypot(nx, ny)/math.Sqrt2, 1)
+			t := math.Max(0, (d-0.45)/0.55)
+			a := byte(math.Min(strength, 1) * t * t * (3 - 2*t) * 255)
+			i := (y*w + x) * 4
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = a, a, a, a
+		}
+	}
+	img := ebiten.NewImage(w, h)
+	img.WritePixels(pix)
+	return img
+}
@@ -0,0 +1,124 @@
+package overlay
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"github.com/hajimehoshi/bitmapfont/v4"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+const dpi = 72
+
+// fallbackFontSize is the pixel height of bitmapfont.Face.
+const fallbackFontSize = 12
+
+// loadFont parses the first of FontPaths that loads. Relative paths are
+// taken from AssetsDir.
+func loadFont(cfg Config) (*opentype.Font, error) {
+	for _, p := range cfg.FontPaths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(cfg.AssetsDir, p)
+		}
+		data := mustReadFile(p)
+		if data == nil {
+			continue
+		}
+		tt, err := opentype.Parse(data)
+		if err != nil {
+			logWarn("Font Error:", p, err)
+			continue
+		}
+		logInfo("Font:", p)
+		return tt, nil
+	}
+	return nil, fmt.Errorf("no usable font in %v", cfg.FontPaths)
+}
+
+// setFaces builds the game's faces from tt at the configured sizes.
+func (g *Game) setFaces(tt *opentype.Font) {
+	g.tt = tt
+	g.sized = nil
+	g.fontWarning = ""
+	g.jpFace, _ = opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    g.cfg.FontSize,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
+	g.jpFaceBig, _ = opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    g.cfg.FontSizeBig,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
+
+	if len(g.cfg.Watermarks) > 0 {
+		g.watermarkFace, _ = opentype.NewFace(tt, &opentype.FaceOptions{
+			Size:    g.cfg.WatermarkSize,
+			DPI:     dpi,
+			Hinting: font.HintingNone,
+		})
+	}
+}
+
+// setFallbackFaces is the last resort when no configured font loads:
+// Ebiten's bitmap font for words and romaji, scaled up in drawBarrage,
+// and a warning on screen so the missing font is not a mystery.
+func (g *Game) setFallbackFaces(err error) {
+	g.tt = nil
+	g.sized = nil
+	g.jpFace = bitmapfont.Face
+	g.jpFaceBig = bitmapfont.Face
+	g.watermarkFace = nil
+	g.fontWarning = fmt.Sprintf("FONT MISSING (%v): using the bitmap fallback", err)
+}
+
+// wordFace picks the face a word at scale is rasterized in, and the
+// scale that face renders at relative to FontSizeBig. Words drawn
+// smaller than FontSizeBig get a face at (about) their on-screen size,
+// since a shrunk big raster goes soft; larger words keep the big face.
+func (g *Game) wordFace(scale float64) (font.Face, float64) {
+	if g.jpFaceBig == bitmapfont.Face {
+		return g.jpFaceBig, fallbackFontSize / g.cfg.FontSizeBig
+	}
+	pt := math.Ceil(g.cfg.FontSizeBig * scale)
+	if g.tt == nil || pt >= g.cfg.FontSizeBig || pt < 1 {
+		return g.jpFaceBig, 1
+	}
+	face, ok := g.sized[int(pt)]
+	if !ok {
+		var err error
+		face, err = opentype.NewFace(g.tt, &opentype.FaceOptions{
+			Size:    pt,
+			DPI:     dpi,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return g.jpFaceBig, 1
+		}
+		if g.sized == nil {
+			g.sized = make(map[int]font.Face)
+		}
+		g.sized[int(pt)] = face
+	}
+	return face, pt / g.cfg.FontSizeBig
+}
+
+// reloadFont re-reads the font from disk and swaps it in, dropping every
+// cached word image so the barrage re-renders in the new face. Called
+// from Update with the lock held, so Draw never sees a half swap.
+func (g *Game) reloadFont() {
+	tt, err := loadFont(g.cfg)
+	if err != nil {
+		logError("Font Reload Error:", err)
+		return
+	}
+	g.setFaces(tt)
+
+	for i := range g.barrage {
+		g.barrage[i].Image = nil
+	}
+	g.watermarkImg = nil
+	g.bounds = nil
+}
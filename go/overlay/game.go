@@ -0,0 +1,1280 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+// Config
+const (
+	// Spawn override limits
+	MinWordScale    = 0.1
+	MaxWordScale    = 8.0
+	MaxWordVelocity = 50.0
+	MaxVelocityMult = 4.0
+	MaxWordImage    = 2048 // px, per side of a cached word image
+
+	romajiGap = 8 // px between a word and its romaji line
+
+	maxRestTilt    = 0.08 // rad a resting word may keep leaning
+	maxBoundsCache = 4096 // measured strings kept before the cache resets
+
+	monologueTop  = 150.0 // px above the first / below the last monologue row
+	monologueRowH = 90.0  // px between stacked monologue words
+
+)
+
+// Colors (Shaft Style)
+var (
+	ColRed    = color.RGBA{198, 40, 40, 255}
+	ColBlack  = color.RGBA{10, 10, 10, 255}
+	ColWhite  = color.RGBA{240, 240, 240, 255}
+	ColYellow = color.RGBA{253, 216, 53, 255}
+	ColCyan   = color.RGBA{0, 255, 255, 255}
+)
+
+type State struct {
+	CurrentState string
+	Strength     float64 // effect intensity, 1 = nominal
+}
+
+type Game struct {
+	mu        sync.RWMutex
+	state     State
+	jpFace    font.Face
+	jpFaceBig font.Face
+	sized     map[int]font.Face             // Word faces below FontSizeBig, by point size
+	tt        *opentype.Font                // Source of the sized faces
+	bounds    map[boundsKey]image.Rectangle // Measured text per face
+
+	fontWarning string // Shown on screen while on the bitmap fallback font
+	showDebug   bool   // Corner readout (F3)
+
+	transcript transcriptPanel
+	lastHeard  heardResult
+
+	// Logical resolution (from config)
+	width, height float64
+
+	// Background kanji motif (index past the end = hidden)
+	watermarkFace font.Face
+	watermark     int
+	watermarkImg  *ebiten.Image
+
+	cfg Config
+
+	spawnEvents chan SpawnEvent // nil until SpawnEvents is called
+
+	// Presets: cfg as loaded at startup, the active index (-1 = startup)
+	// and the blend in progress
+	baseCfg Config
+	preset  int
+	fade    *presetFade
+
+	reloads chan Config // Validated config files from the watcher
+
+	// Logic
+	brain *Brain
+	rng   *rand.Rand // Update and spawning, under the lock
+
+	drawRng *rand.Rand // Draw only, on the render goroutine
+
+	// Audio
+	speech       *SpeechEngine
+	metrics      *metrics
+	ndi          *videoOutput
+	vcam         *videoOutput
+	recorder     *recorder
+	audioChan    chan float64
+	textChan     chan Utterance
+	spectrumChan chan Bands
+
+	micVolume  float64 // 0.0 - 1.0 (Smoothed)
+	peakVolume float64
+	bands      Bands // Bass / Mid / Treble (Smoothed)
+
+	// Visuals
+	frameCount  int
+	clock       float64 // seconds of unpaused time, drives the animated geometry
+	videoGlitch float64 // For Shaft cut effect
+	words       []string
+	barrage     []BarrageWord
+
+	// Effects
+	shakeAmount    float64
+	flashIntensity float64
+	gears          []Gear
+	particles      []Particle
+	grid           spatialGrid // Barrage buckets for area effects
+	glitchBands    []glitchBand
+	offscreen      *ebiten.Image // Scene buffer for post-processing
+	waveform       []float32     // Oscilloscope samples (Draw only)
+	grain          *ebiten.Image // Film grain tile
+	vignette       *ebiten.Image // Radial edge gradient (white, alpha)
+	grainX, grainY int           // This frame's offset into the tile
+	shockwaves     []Shockwave
+	particleNext   int // Next pool slot to recycle when full
+	geomRotation   float64
+	alignment      float64 // Brain.Alignment, eased
+	letterbox      float64 // 0: hidden, 1: bars fully in
+
+	// World inversion
+	gravityDir    float64 // 1: normal, -1: upside down (eased)
+	invertUntil   time.Time
+	invertLatched bool
+	mirrorX       float64 // 1: normal, -1: mirrored left/right (eased)
+	mirrorUntil   time.Time
+	mirrorLatched bool
+
+	// Color inversion (0: off, 1: fully inverted)
+	colorInvert      float64
+	colorInvertUntil time.Time
+
+	// Synesthetic state
+	bgColor       color.RGBA
+	targetBgColor color.RGBA
+
+	// Conversation State
+	// Handled by Brain now
+	currentSpeaker int // 0: Left, 1: Right
+	heardSpeaker   int // speaker named by the input being spawned, -1 = none
+	channelChan    chan [2]float64
+	channelLevel   [2]float64 // slow per-mic energy for stereo speaker mapping
+	uttEnergy      utteranceEnergy
+	speakerLevel   [2]float64 // typical mono level per speaker (0 = not heard yet)
+	lastWordTime   time.Time
+	monologueStart time.Time // TurnStart of the monologue being stacked
+	monologueRow   int
+
+	// Operator typing
+	typeBuffer []rune
+
+	// Chunks of a split phrase waiting to spawn
+	pending []pendingWord
+
+	// Spawn limiter bucket
+	spawnTokens float64
+	spawnRefill time.Time
+
+	// Pause
+	paused   bool
+	pausedAt time.Time
+
+	lastDraw time.Time // For the FPS cap
+
+	// Set up by New, used by Run
+	fullscreen bool
+	closers    []func()
+}
+
+type Gear struct {
+	X, Y, Radius, Rotation, Speed float64
+	Teeth                         int
+	Color                         color.RGBA
+}
+
+type BarrageWord struct {
+	Text     string
+	X, Y     float64
+	VX, VY   float64
+	Scale    float64
+	Color    color.Color
+	Life     int
+	MaxLife  int
+	IsGlitch bool
+
+	// Physics
+	Rotation     float64
+	VRotation    float64
+	IsResting    bool
+	RestRotation float64 // settled tilt while resting
+	IsFiller     bool
+	IsSticky     bool // Adheres to the side walls instead of bouncing
+
+	Gravity float64 // Factor from the style table (0 = default)
+
+	IsTypewriter bool // Revealed left-to-right, rune by rune
+
+	// Shatter: one rune of a burst word, springing back to its slot
+	IsShard      bool
+	HomeX, HomeY float64
+
+	// Orbit: circles the center while ALIGNED, released on state change
+	IsOrbiting  bool
+	OrbitAngle  float64
+	OrbitRadius float64
+
+	// Visual Cache
+	Image       *ebiten.Image
+	ScaleX      float64
+	ScaleY      float64 // Extra vertical stretch (0 = 1)
+	RasterScale float64 // Scale Image was rasterized at (0 = 1)
+}
+
+// NewGame builds a Game around a Brain with the given clock.
+// All visual randomness is drawn from a source seeded with seed,
+// so the same seed, clock and input produce the same frames.
+func NewGame(cfg Config, seed int64, now func() time.Time) *Game {
+	brain := NewBrainWithClock(cfg.Brain, now)
+	brain.Highlights = cfg.Highlights
+
+	g := &Game{
+		cfg:        cfg,
+		baseCfg:    cfg,
+		preset:     -1,
+		brain:      brain,
+		rng:        rand.New(rand.NewSource(seed)),
+		width:      float64(cfg.Width),
+		height:     float64(cfg.Height),
+		state:      State{Strength: 1},
+		gravityDir: 1.0,
+		mirrorX:    1.0,
+	}
+	g.drawRng = rand.New(rand.NewSource(seed + 1))
+	g.heardSpeaker = -1
+	g.showDebug = cfg.ShowDebug
+	g.transcript.visible = cfg.Transcript
+	g.bgColor = color.RGBA{A: 255}
+	g.targetBgColor = g.bgColor
+	brain.OnStateChange = g.onStateChange
+	brain.OnResolve = g.onResolve
+	return g
+}
+
+// onStateChange fires the one-shot effects for a Brain state transition.
+// It runs inside Update (via GetState) with the lock held.
+func (g *Game) onStateChange(change StateChange) {
+	logDebug("State:", change.From, "->", change.To)
+	if change.To == "SPLIT" {
+		g.emitShockwaves()
+	}
+}
+
+// onResolve washes the background cool and lets a few motes drift up
+// as the Brain lets go of a conflict. Runs inside Update.
+func (g *Game) onResolve() {
+	logDebug("Resolve: tension", g.brain.Tension)
+	if !g.cfg.Monochrome {
+		g.targetBgColor = color.RGBA{10, 25, 60, 255}
+	}
+	g.driftParticles(40, g.monoColor(color.RGBA{200, 200, 255, 160}))
+}
+
+func (g *Game) Update() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// 0. Hotkeys, Config Reloads & Pause (hold the current frame)
+	g.updateHotkeys()
+	select {
+	case cfg := <-g.reloads:
+		g.applyReload(cfg)
+	default:
+	}
+	if g.paused {
+		g.drainInput()
+		return nil
+	}
+
+	g.frameCount++
+
+	// Init Gears (Lazy)
+	if len(g.gears) == 0 {
+		g.initGears()
+	}
+
+	// 1. Audio Processing
+	if g.audioChan == nil {
+		g.audioChan = make(chan float64, 10)
+	}
+	select {
+	case vol := <-g.audioChan:
+		target := g.volumeLevel(vol)
+		if target > g.micVolume {
+			g.micVolume += (target - g.micVolume) * (1 - math.Pow(1-g.cfg.VolumeAttack, g.tickScale()))
+		} else {
+			g.micVolume *= math.Pow(g.cfg.VolumeDecay, g.tickScale())
+		}
+	default:
+		g.micVolume *= math.Pow(g.cfg.VolumeIdleDecay, g.tickScale())
+	}
+
+	select {
+	case raw := <-g.spectrumChan:
+		for i := range raw {
+			k := math.Pow(g.cfg.BandSmoothing[i], g.tickScale())
+			g.bands[i] = g.bands[i]*k + raw[i]*g.cfg.VolumeGain*(1-k)
+		}
+	default:
+	}
+
+	g.updateChannelLevels()
+
+	// 2. Consume Speech (Brain Input)
+	select {
+	case u := <-g.textChan:
+		// Process via Brain
+		logDebug("Heard:", u.Text, "conf", u.Conf)
+		g.metrics.recognized()
+		g.hear(u)
+	default:
+		// No speech
+	}
+	g.spawnPending()
+	g.updateTyping()
+
+	// 3. Check Silence (Brain Loop)
+	if _, cfg, ok := g.brain.CheckSilence(); ok {
+		g.spawnWordFromConfig(cfg)
+	}
+
+	// 4. Update State
+	g.state.CurrentState = g.brain.GetState()
+	g.state.Strength = g.stateStrength()
+	g.alignment += (g.brain.Alignment - g.alignment) * (1 - math.Pow(0.98, g.tickScale()))
+
+	// 5. Update Physics & Effects
+	g.updatePresetFade(1 / float64(ebiten.TPS()))
+	g.updatePhysics()
+	g.metrics.observe(g, 1/float64(ebiten.TPS()))
+
+	return nil
+}
+
+func (g *Game) togglePause() {
+	now := g.brain.Now()
+	if !g.paused {
+		g.paused = true
+		g.pausedAt = now
+		return
+	}
+
+	// Resume: push every clock forward so the pause never happened
+	g.paused = false
+	held := now.Sub(g.pausedAt)
+	g.brain.SkipTime(held)
+	g.lastWordTime = g.lastWordTime.Add(held)
+	for i := range g.pending {
+		g.pending[i].due = g.pending[i].due.Add(held)
+	}
+	g.invertUntil = g.invertUntil.Add(held)
+	g.mirrorUntil = g.mirrorUntil.Add(held)
+	g.colorInvertUntil = g.colorInvertUntil.Add(held)
+}
+
+// drainInput discards audio and speech that arrives while paused,
+// so nothing stale bursts out on resume.
+func (g *Game) drainInput() {
+	select {
+	case <-g.audioChan:
+	default:
+	}
+	select {
+	case <-g.spectrumChan:
+	default:
+	}
+	select {
+	case <-g.channelChan:
+	default:
+	}
+	select {
+	case <-g.textChan:
+	default:
+	}
+}
+
+// stateStrength is how far into its state the Brain is: 1 normally,
+// rising with tension past the split threshold (capped at StrengthMax).
+func (g *Game) stateStrength() float64 {
+	if g.state.CurrentState != "SPLIT" {
+		return 1
+	}
+	s := g.brain.Tension / g.cfg.Brain.SplitThreshold
+	return math.Max(1, math.Min(s, g.cfg.StrengthMax))
+}
+
+// volumeLevel maps a raw RMS reading to the level that drives visuals:
+// linear times VolumeGain, or with VolumeDB the dB range mapped to 0..1.
+func (g *Game) volumeLevel(rms float64) float64 {
+	if !g.cfg.VolumeDB {
+		return rms * g.cfg.VolumeGain
+	}
+	if rms <= 0 {
+		return 0
+	}
+	db := 20 * math.Log10(rms)
+	t := (db - g.cfg.VolumeDBFloor) / (g.cfg.VolumeDBCeil - g.cfg.VolumeDBFloor)
+	return math.Max(0, math.Min(1, t))
+}
+
+// tickScale is the length of one tick relative to the 60 TPS the
+// physics constants were tuned at (2.0 at 30 TPS).
+func (g *Game) tickScale() float64 {
+	return 60.0 / float64(ebiten.TPS())
+}
+
+func (g *Game) updatePhysics() {
+	dt := g.tickScale()
+
+	// Decay Effects
+	g.shakeAmount *= math.Pow(0.9, dt)
+	if g.shakeAmount < 0.5 {
+		g.shakeAmount = 0
+	}
+	g.flashIntensity *= math.Pow(0.85, dt)
+	g.clock += dt / 60
+	g.updateLetterbox()
+	g.updateParticles()
+	g.transcript.scroll *= math.Pow(0.8, dt)
+	g.updateShockwaves()
+	g.updateGlitchBands()
+	g.updateGravityDir()
+	g.updateMirror()
+	g.updateColorInvert()
+
+	// Rotate Gears
+	for i := range g.gears {
+		g.gears[i].Rotation += g.gears[i].Speed * dt
+	}
+	g.geomRotation += (g.cfg.GeomSpin + g.bands[BandTreble]*g.cfg.TrebleSpin) * g.state.Strength * dt
+
+	// Update Barrage
+	g.applyAreaForces()
+	g.applyAlignPull(dt)
+	g.stepBarrage(dt)
+
+	// Color Logic
+	if g.state.CurrentState == "SPLIT" && !g.cfg.Monochrome {
+		g.targetBgColor = g.splitBgColor()
+	}
+	target := g.targetBgColor
+	if c, ok := g.namedColor(g.cfg.AlignColor); ok && g.state.CurrentState != "SPLIT" && !g.cfg.Monochrome {
+		target = lerpColor(target, c, g.alignment)
+	}
+	g.bgColor = lerpColor(g.bgColor, target, 0.05)
+}
+
+func (g *Game) spawnWordFromConfig(cfg WordConfig) {
+	if strings.TrimSpace(cfg.Text) == "" {
+		return // Nothing to draw; punctuation-only silence cues still pass
+	}
+
+	// Turn Logic (Simplified)
+	newTurn := !strings.HasPrefix(cfg.Style, "silence_") && !cfg.Continuation
+	if g.heardSpeaker >= 0 && newTurn {
+		g.currentSpeaker = g.heardSpeaker
+		g.lastWordTime = g.brain.Now()
+	} else if g.cfg.TurnStrategy == "energy" {
+		if newTurn {
+			g.speakerFromEnergy()
+			g.lastWordTime = g.brain.Now()
+		}
+	} else if g.cfg.StereoInput {
+		g.speakerFromChannels()
+	} else if newTurn {
+		now := g.brain.Now()
+		if now.Sub(g.lastWordTime) > 2000*time.Millisecond || cfg.Style == "conjunction" {
+			g.currentSpeaker = (g.currentSpeaker + 1) % 2
+		}
+		g.lastWordTime = now
+	}
+	if newTurn {
+		g.brain.NoteTurn(g.currentSpeaker)
+	}
+
+	// Apply Config
+	style := cfg.Style
+	text := cfg.Text
+
+	if style != "glitch" && style != "impact" && g.state.CurrentState != "SPLIT" && !g.cfg.Monochrome {
+		if style == "conjunction" {
+			g.targetBgColor = color.RGBA{50, 50, 50, 255}
+		} else {
+			g.targetBgColor = textToColor(text)
+		}
+	}
+
+	// Physics Defaults
+	nuanceScale := 1.0 + (g.micVolume * g.cfg.NuanceGain)
+	if nuanceScale > g.cfg.NuanceScaleMax {
+		nuanceScale = g.cfg.NuanceScaleMax
+	}
+
+	scale := nuanceScale + g.rng.Float64()*0.5
+	life := 600
+	colorVal := ColWhite
+	scaleX := 1.0
+
+	startX := 0.0
+	startY := 0.0
+	vx := 0.0
+	vy := 0.0
+	rot := (g.rng.Float64() - 0.5) * 0.5
+	vrot := (g.rng.Float64() - 0.5) * 0.1
+	stacked := false // monologue column: placed, not thrown
+
+	// Base Positioning: styles in the table with an origin are placed,
+	// everything else is thrown from the speaker's side
+	spec, styled := g.cfg.Styles[style]
+	if styled && spec.Origin != "" {
+		startX, startY = g.styleOrigin(spec.Origin)
+		vx = spec.VX + g.spread(spec.Spread[0])
+		vy = spec.VY + g.spread(spec.Spread[1])
+	} else {
+		switch {
+		case g.state.CurrentState == "SPLIT":
+			startX = g.width/2 + (g.rng.Float64()-0.5)*g.cfg.Launch.EruptSpread
+			vx = (g.rng.Float64() - 0.5) * 10
+			startY, vy = g.launchY()
+		case g.brain.Monologue():
+			startX, startY = g.monologueSlot()
+			rot, vrot = 0, 0
+			stacked = true
+		case g.portrait():
+			// Speakers top and bottom: the top one drops words, the bottom one throws them up
+			ax, ay := g.speakerAnchor(g.currentSpeaker)
+			startX = ax + g.rng.Float64()*200 - 100
+			startY = ay + g.rng.Float64()*100 - 50
+			vx = (g.rng.Float64() - 0.5) * g.cfg.Launch.VXRand
+			if g.currentSpeaker == 1 {
+				vy = g.cfg.Launch.VY - g.rng.Float64()*g.cfg.Launch.VYRand - 2*g.cfg.Launch.VX
+			}
+		case g.currentSpeaker == 0:
+			startX = g.width*0.2 + g.rng.Float64()*100
+			vx = g.cfg.Launch.VX + g.rng.Float64()*g.cfg.Launch.VXRand
+			startY, vy = g.launchY()
+		default:
+			startX = g.width*0.8 - g.rng.Float64()*100
+			vx = -g.cfg.Launch.VX - g.rng.Float64()*g.cfg.Launch.VXRand
+			startY, vy = g.launchY()
+		}
+	}
+
+	if styled {
+		if spec.Scale > 0 {
+			scale = spec.Scale
+		}
+		if spec.ScaleMult > 0 {
+			scale *= spec.ScaleMult
+		}
+		if spec.Life > 0 {
+			life = spec.Life
+		}
+		if c, ok := g.namedColor(spec.Color); ok {
+			colorVal = c
+		}
+		if spec.Still {
+			rot, vrot = 0, 0
+		}
+	}
+	if style == "thinking" {
+		// Gathering thoughts: trail dots below
+		g.spawnThoughtDots(startX, startY)
+	}
+
+	// Apply Overrides from Config (clamped: one bad value must not wreck the frame)
+	if cfg.Rot != 0 {
+		rot = clampOverride(cfg.Rot, -2*math.Pi, 2*math.Pi, rot)
+	}
+	if cfg.ScaleX != 1.0 { // Default is 1.0
+		scaleX = clampOverride(cfg.ScaleX, -MaxWordScale, MaxWordScale, scaleX)
+	}
+	if cfg.VY != 0 {
+		vy = clampOverride(cfg.VY, -MaxWordVelocity, MaxWordVelocity, vy)
+	}
+	if cfg.VYMult != 1.0 {
+		vy *= clampOverride(cfg.VYMult, -MaxVelocityMult, MaxVelocityMult, 1.0)
+		vy = clampOverride(vy, -MaxWordVelocity, MaxWordVelocity, 0)
+	}
+	if cfg.Scale != 1.0 {
+		scale = clampOverride(cfg.Scale, MinWordScale, MaxWordScale, scale)
+	}
+
+	// Color String to Color
+	if c, ok := g.namedColor(cfg.Color); ok {
+		colorVal = c
+	}
+
+	// Effects
+	if cfg.Shake > 0 {
+		g.shakeAmount += cfg.Shake * g.state.Strength
+	}
+	if cfg.Flash {
+		g.flashIntensity = 1.0
+	}
+	if style == "invert_v" {
+		g.triggerInvertV()
+	}
+	if style == "invert_h" {
+		g.triggerInvertH()
+	}
+	if style == "invert_c" {
+		g.triggerInvertC()
+	}
+	if style == "impact" {
+		g.burstParticles(startX, startY)
+	}
+
+	bw := BarrageWord{
+		Text:      text,
+		X:         startX,
+		Y:         startY,
+		VX:        vx,
+		VY:        vy,
+		Scale:     scale,
+		ScaleX:    scaleX,
+		Color:     g.monoColor(colorVal),
+		Life:      life,
+		MaxLife:   life,
+		IsGlitch:  (style == "glitch" || style == "impact"),
+		Rotation:  rot,
+		VRotation: vrot,
+		IsResting: stacked,
+		Image:     nil,
+		IsFiller:  (len(text) <= 3) && !strings.HasPrefix(style, "silence_"),
+		IsSticky:  style == "stick" || g.rng.Float64() < g.cfg.StickyChance,
+	}
+	bw.RestRotation = rot // Stacked words rest as spawned
+	bw.Gravity = spec.Gravity
+	bw.ScaleY = g.shoutStretch()
+	if style == "thinking" {
+		bw.IsFiller = true // Light gravity: hangs near the speaker
+	}
+	bw.IsTypewriter = style == "typewriter" ||
+		(g.cfg.TypewriterMinRunes > 0 && !bw.IsFiller && !strings.HasPrefix(style, "silence_") &&
+			utf8.RuneCountInString(text) >= g.cfg.TypewriterMinRunes)
+
+	// Life is counted in ticks; keep it constant in seconds at any TPS
+	bw.Life = int(float64(life) / g.tickScale())
+	bw.MaxLife = bw.Life
+
+	if g.state.CurrentState == "SPLIT" || style == "glitch" {
+		bw.Color = ColRed
+		boost := 1 + (g.cfg.Launch.SplitBoost-1)*g.state.Strength
+		bw.VX *= boost
+		bw.VY *= boost
+	}
+
+	g.emitSpawn(bw, style)
+
+	if style == "shatter" || (style == "impact" && g.cfg.ShatterChance > 0 &&
+		utf8.RuneCountInString(text) <= g.cfg.ShatterMaxRunes && g.rng.Float64() < g.cfg.ShatterChance) {
+		g.spawnShards(bw)
+		return
+	}
+
+	g.startOrbit(&bw)
+	g.barrage = append(g.barrage, bw)
+}
+
+// monoColor reduces c to white, keeping its alpha, in monochrome mode.
+// Red survives as the single accent for SPLIT and impacts.
+func (g *Game) monoColor(c color.RGBA) color.RGBA {
+	if !g.cfg.Monochrome || c == ColRed {
+		return c
+	}
+	return color.RGBA{c.A, c.A, c.A, c.A}
+}
+
+// spawnThoughtDots trails a few small floating "・" below (x, y), like a
+// thought bubble's tail.
+func (g *Game) spawnThoughtDots(x, y float64) {
+	for i := 1; i <= 3; i++ {
+		life := 400 - i*40
+		g.barrage = append(g.barrage, BarrageWord{
+			Text:     "・",
+			X:        x + (g.rng.Float64()-0.5)*30,
+			Y:        y + float64(i)*45,
+			VX:       (g.rng.Float64() - 0.5) * 0.3,
+			VY:       -0.4,
+			Scale:    0.5 + 0.15*float64(3-i),
+			ScaleX:   1,
+			Color:    g.monoColor(color.RGBA{100, 100, 100, 100}),
+			Life:     life,
+			MaxLife:  life,
+			IsFiller: true,
+		})
+	}
+}
+
+// splitBgColor is the SPLIT background: SplitColor darkened to
+// SplitColorDim at strength 1, reaching full color at StrengthMax.
+func (g *Game) splitBgColor() color.RGBA {
+	c, ok := g.namedColor(g.cfg.SplitColor)
+	if !ok {
+		c = ColRed
+	}
+	k := 1.0
+	if g.cfg.StrengthMax > 1 {
+		k = (g.state.Strength - 1) / (g.cfg.StrengthMax - 1)
+	}
+	dim := g.cfg.SplitColorDim + (1-g.cfg.SplitColorDim)*k
+	return lerpColor(color.RGBA{A: 255}, c, dim)
+}
+
+// monologueSlot returns the next row of the current speaker's column.
+// Rows wrap to the top and restart with each new monologue.
+func (g *Game) monologueSlot() (float64, float64) {
+	if !g.brain.TurnStart.Equal(g.monologueStart) {
+		g.monologueStart = g.brain.TurnStart
+		g.monologueRow = 0
+	}
+	// Landscape: a full-height column on the speaker's side.
+	// Portrait: a centered column in the speaker's half.
+	x, top, bottom := g.width*0.15, monologueTop, g.height-monologueTop
+	if g.currentSpeaker == 1 {
+		x = g.width * 0.85
+	}
+	if g.portrait() {
+		x = g.width / 2
+		if g.currentSpeaker == 0 {
+			bottom = g.height / 2
+		} else {
+			top = g.height / 2
+		}
+	}
+
+	rows := max(int((bottom-top)/monologueRowH), 1)
+	y := top + float64(g.monologueRow%rows)*monologueRowH
+	g.monologueRow++
+	return x, y
+}
+
+// portrait reports a screen taller than wide. Speakers then sit at the
+// top and bottom instead of left and right.
+func (g *Game) portrait() bool {
+	return g.height > g.width
+}
+
+// speakerAnchor is where a speaker's words come from.
+func (g *Game) speakerAnchor(speaker int) (float64, float64) {
+	if g.portrait() {
+		if speaker == 0 {
+			return g.width / 2, g.height * 0.25
+		}
+		return g.width / 2, g.height * 0.75
+	}
+	if speaker == 0 {
+		return g.width * 0.2, g.height * g.cfg.Launch.SpeakerY[0]
+	}
+	return g.width * 0.8, g.height * g.cfg.Launch.SpeakerY[1]
+}
+
+// shoutStretch is the vertical stretch for a word spawned now: 1 up to
+// ShoutThreshold, rising to 1+ShoutStretch at full volume.
+func (g *Game) shoutStretch() float64 {
+	if g.cfg.ShoutStretch <= 0 || g.cfg.ShoutThreshold >= 1 {
+		return 1
+	}
+	t := (g.micVolume - g.cfg.ShoutThreshold) / (1 - g.cfg.ShoutThreshold)
+	return 1 + g.cfg.ShoutStretch*math.Max(0, math.Min(t, 1))
+}
+
+// styleOrigin is where a placed style appears (see StylePhysics.Origin).
+func (g *Game) styleOrigin(origin string) (float64, float64) {
+	switch origin {
+	case "erupt":
+		return g.width/2 + (g.rng.Float64()-0.5)*g.cfg.Launch.EruptSpread, 0
+	case "anywhere":
+		return g.rng.Float64() * g.width, g.rng.Float64() * g.height
+	case "speaker":
+		ax, ay := g.speakerAnchor(g.currentSpeaker)
+		return ax + (g.rng.Float64()-0.5)*160, ay - 60
+	case "middle":
+		return g.width / 2, g.height / 3
+	case "top":
+		return 100 + g.rng.Float64()*(g.width-200), -100
+	case "bottom":
+		return 100 + g.rng.Float64()*(g.width-200), g.height + 100
+	}
+	return g.width / 2, g.height / 2
+}
+
+// spread is a random offset in [-w/2, w/2), drawing nothing when w is 0.
+func (g *Game) spread(w float64) float64 {
+	if w == 0 {
+		return 0
+	}
+	return (g.rng.Float64() - 0.5) * w
+}
+
+// launchY is the landscape spawn height, around the current speaker's
+// SpeakerY, and the upward throw.
+func (g *Game) launchY() (float64, float64) {
+	y := g.cfg.Launch.SpeakerY[1]
+	if g.currentSpeaker == 0 {
+		y = g.cfg.Launch.SpeakerY[0]
+	}
+	return g.height*y + g.rng.Float64()*200 - 100, g.cfg.Launch.VY - g.rng.Float64()*g.cfg.Launch.VYRand
+}
+
+// namedColor maps the color names used in WordConfig and the config to
+// colors: the configured Colors table first, then the built-in names,
+// then a "#RRGGBB" or "#RRGGBBAA" string. Anything else (including
+// "white") reports false and keeps the default.
+func (g *Game) namedColor(name string) (color.RGBA, bool) {
+	if hex, ok := g.cfg.Colors[name]; ok {
+		if c, err := parseHexColor(hex); err == nil {
+			return c, true
+		}
+	}
+	if c, ok := builtinColor(name); ok {
+		return c, true
+	}
+	if strings.HasPrefix(name, "#") {
+		if c, err := parseHexColor(name); err == nil {
+			return c, true
+		}
+	}
+	return color.RGBA{}, false
+}
+
+// parseHexColor reads "#RRGGBB" or "#RRGGBBAA" (alpha defaults to opaque).
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 || !strings.HasPrefix(s, "#") {
+		return color.RGBA{}, fmt.Errorf("bad color %q, want #RRGGBB or #RRGGBBAA", s)
+	}
+	return color.RGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+func builtinColor(name string) (color.RGBA, bool) {
+	switch name {
+	case "cyan":
+		return ColCyan, true
+	case "red":
+		return ColRed, true
+	case "yellow":
+		return ColYellow, true
+	case "grey":
+		return color.RGBA{200, 200, 200, 150}, true
+	case "dark_grey":
+		return color.RGBA{50, 50, 50, 255}, true
+	case "black":
+		return color.RGBA{5, 5, 20, 255}, true
+	case "blue_white":
+		return color.RGBA{200, 200, 255, 200}, true
+	case "grey_alpha":
+		return color.RGBA{100, 100, 100, 100}, true
+	}
+	return color.RGBA{}, false
+}
+
+func (g *Game) initGears() {
+	g.gears = []Gear{
+		{X: 100, Y: 100, Radius: 150, Teeth: 12, Speed: 0.005, Color: color.RGBA{40, 40, 40, 255}},
+		{X: g.width - 100, Y: g.height - 150, Radius: 200, Teeth: 16, Speed: -0.003, Color: color.RGBA{30, 30, 30, 255}},
+		{X: g.width / 2, Y: -100, Radius: 300, Teeth: 24, Speed: 0.002, Color: color.RGBA{20, 20, 20, 255}},
+	}
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	g.mu.RLock()
+	currentState := g.state.CurrentState
+	shake := g.shakeAmount
+	flash := g.flashIntensity
+	paused := g.paused
+	strength := g.state.Strength
+	g.mu.RUnlock()
+
+	// FPS cap: skip redraws and let the previous frame stay on screen
+	if g.cfg.FPSCap > 0 {
+		now := time.Now()
+		if now.Sub(g.lastDraw) < time.Second/time.Duration(g.cfg.FPSCap) {
+			return
+		}
+		g.lastDraw = now
+	}
+
+	dx, dy := 0.0, 0.0
+	if shake > 0 && !paused {
+		dx = (g.drawRng.Float64() - 0.5) * shake
+		dy = (g.drawRng.Float64() - 0.5) * shake
+	}
+
+	// Scene (offscreen when a post-process needs it)
+	g.mu.Lock() // sceneTarget builds the offscreen image
+	scene := g.sceneTarget(screen)
+	g.mu.Unlock()
+
+	g.mu.RLock()
+	bg := g.bgColor
+	if g.colorInvert > 0 && g.cfg.ColorInvertCPU {
+		bg = invertRGBA(bg, g.colorInvert)
+	}
+	g.mu.RUnlock()
+	scene.Fill(bg)
+	g.drawGears(scene, dx, dy)
+	g.drawGeometry(scene, dx, dy)
+	g.drawWatermark(scene)
+	g.drawBarrage(scene, dx, dy)
+	g.drawParticles(scene, dx, dy)
+
+	g.mu.RLock()
+	g.postProcess(screen, scene)
+	g.mu.RUnlock()
+
+	// Overlays, drawn over the composite: each applies Opacity itself
+	g.drawVignette(screen, currentState)
+	g.drawGrain(screen, paused)
+	g.drawLetterbox(screen)
+
+	if flash > 0.01 {
+		// Stronger SPLITs flash redder
+		red := math.Max(0, math.Min(strength-1, 1))
+		c := lerpColor(color.RGBA{255, 255, 255, 255}, ColRed, red)
+		vector.DrawFilledRect(screen, 0, 0, float32(g.width), float32(g.height), color.RGBA{c.R, c.G, c.B, uint8(flash * g.cfg.Opacity * 255)}, true)
+	}
+
+	g.drawTranscript(screen)
+
+	// Video feeds get the show without the operator UI
+	g.ndi.capture(screen)
+	g.vcam.capture(screen)
+	g.recorder.capture(screen)
+	g.drawTypeBuffer(screen)
+
+	g.drawDebug(screen)
+	if g.fontWarning != "" {
+		ebitenutil.DebugPrintAt(screen, g.fontWarning, 0, int(g.height)-16)
+	}
+}
+
+func (g *Game) drawGears(screen *ebiten.Image, dx, dy float64) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, gear := range g.gears {
+		cx, cy := float32(gear.X+dx), float32(gear.Y+dy)
+		vector.DrawFilledCircle(screen, cx, cy, float32(gear.Radius), gear.Color, true)
+		for i := 0; i < gear.Teeth; i++ {
+			theta := gear.Rotation + (float64(i) / float64(gear.Teeth) * 2 * math.Pi)
+			tx := cx + float32(math.Cos(theta))*float32(gear.Radius+20)
+			ty := cy + float32(math.Sin(theta))*float32(gear.Radius+20)
+			vector.StrokeLine(screen, cx, cy, tx, ty, 20, gear.Color, true)
+		}
+		vector.DrawFilledCircle(screen, cx, cy, float32(gear.Radius*0.3), g.bgColor, true)
+	}
+}
+
+func (g *Game) drawGeometry(screen *ebiten.Image, dx, dy float64) {
+	cx, cy := float32(g.width/2+dx), float32(g.height/2+dy)
+
+	g.mu.RLock()
+	bands := g.bands
+	theta := g.geomRotation
+	flipY := float32(g.gravityDir) // Mirrors vertically with the world
+	currentState := g.state.CurrentState
+	strength := g.state.Strength
+	calm := g.alignment
+	g.mu.RUnlock()
+
+	// Agreement settles the geometry: less swell and thickening
+	for i := range bands {
+		bands[i] *= 1 - calm
+	}
+
+	switch g.cfg.GeometryMode {
+	case "scope":
+		g.drawScope(screen, dx, dy, flipY)
+	case "spectrum":
+		g.drawSpectrum(screen, cx, cy, bands, flipY, currentState == "SPLIT")
+	default:
+		g.drawSplitLine(screen, cx, cy, bands, theta, flipY, currentState, strength)
+	}
+
+	g.mu.RLock()
+	g.drawShockwaves(screen, dx, dy)
+	g.mu.RUnlock()
+}
+
+// drawSplitLine is the rotating diameter: doubled (or fanned) and red
+// during SPLIT.
+func (g *Game) drawSplitLine(screen *ebiten.Image, cx, cy float32, bands Bands, theta float64, flipY float32, currentState string, strength float64) {
+	// Bass swells the circle, mids thicken the line, treble spins it (in Update)
+	radius := float32(200.0 + bands[BandBass]*400.0)
+	thickness := float32(g.cfg.GeomThickness + bands[BandMid]*g.cfg.GeomThicknessPeak)
+	offset := float32(g.cfg.GeomSplitOffset)
+
+	col := ColWhite
+	doubles := 0
+	if currentState == "SPLIT" {
+		col = ColRed
+		doubles = g.cfg.GeomSplitDoubles + int(g.cfg.GeomSplitGrowth*(strength-1))
+	}
+	fan := g.cfg.GeomSplitFan * strength
+
+	// GeomLines diameters spread evenly over half a turn
+	for i := 0; i < max(g.cfg.GeomLines, 1); i++ {
+		a := theta + float64(i)*math.Pi/float64(max(g.cfg.GeomLines, 1))
+		for k := doubles; k >= 0; k-- {
+			ak := a + float64(k)*fan
+			shift := float32(k) * offset
+			x1 := cx + float32(math.Cos(ak))*radius
+			y1 := cy + float32(math.Sin(ak))*radius*flipY
+			x2 := cx - float32(math.Cos(ak))*radius
+			y2 := cy - float32(math.Sin(ak))*radius*flipY
+			vector.StrokeLine(screen, x1+shift, y1, x2+shift, y2, thickness, col, true)
+		}
+	}
+}
+
+func (g *Game) drawBarrage(screen *ebiten.Image, dx, dy float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.jpFaceBig == nil {
+		return
+	}
+
+	// One set of options reused for every word (GeoM reset per word)
+	op := &ebiten.DrawImageOptions{}
+	cop := &colorm.DrawImageOptions{}
+	invert := invertColorM(g.colorInvert)
+
+	for i := range g.barrage {
+		b := &g.barrage[i]
+		if b.Image == nil {
+			if b.Image = g.renderWord(b); b.Image == nil {
+				b.Life = 0 // Nothing visible; free the slot
+				continue
+			}
+		}
+
+		jx, jy := 0.0, 0.0
+		if b.IsGlitch || g.state.CurrentState == "SPLIT" {
+			jx = (g.drawRng.Float64() - 0.5) * g.cfg.GlitchJitter[0] * g.state.Strength
+			jy = (g.drawRng.Float64() - 0.5) * g.cfg.GlitchJitter[1] * g.state.Strength
+		}
+
+		img := b.Image
+		if b.IsTypewriter {
+			if img = g.typewriterClip(b); img == nil {
+				continue
+			}
+		}
+
+		w, h := b.Image.Size()
+		op.GeoM.Reset()
+		op.GeoM.Translate(float64(-w)/2, float64(-h)/2)
+
+		scaleX := b.ScaleX
+		if scaleX == 0 {
+			scaleX = 1.0
+		}
+		if g.mirrorX < 0 && !g.cfg.MirrorGlyphs {
+			scaleX = -scaleX // Undo the world mirror so text stays readable
+		}
+		scale := b.Scale
+		if b.RasterScale > 0 {
+			scale /= b.RasterScale
+		}
+		scaleY := b.ScaleY
+		if scaleY == 0 {
+			scaleY = 1.0
+		}
+		op.GeoM.Scale(scale*scaleX, scale*scaleY)
+
+		rot := b.Rotation
+		if !b.IsResting {
+			rot += 0.1 * math.Sin(g.clock*3)
+		}
+		op.GeoM.Rotate(rot)
+		op.GeoM.Translate(b.X+jx+dx, b.Y+jy+dy)
+
+		if g.colorInvert > 0 && g.cfg.ColorInvertCPU {
+			// Fallback: invert just the words, not the whole frame
+			cop.GeoM = op.GeoM
+			colorm.DrawImage(screen, img, invert, cop)
+			continue
+		}
+		screen.DrawImage(img, op)
+	}
+}
+
+// renderWord rasterizes b's text into its cached image. With romaji
+// enabled the transliteration is set in the small face underneath.
+// Text with degenerate bounds renders nothing and returns nil.
+func (g *Game) renderWord(b *BarrageWord) *ebiten.Image {
+	romaji := ""
+	var rRect image.Rectangle
+	if g.cfg.ShowRomaji && g.jpFace != nil {
+		romaji = toRomaji(b.Text)
+	}
+
+	// Romaji is sized against the big face, so only bare words shrink
+	scale := b.Scale
+	if romaji != "" {
+		scale = 1
+	}
+	face, raster := g.wordFace(scale)
+	b.RasterScale = raster
+	rect := g.boundString(face, b.Text)
+	if rect.Empty() && romaji == "" {
+		return nil
+	}
+	w := rect.Max.X - rect.Min.X + 4
+	h := rect.Max.Y - rect.Min.Y + 4
+
+	if romaji != "" {
+		rRect = g.boundString(g.jpFace, romaji)
+		w = max(w, rRect.Dx()+4)
+		h += rRect.Dy() + romajiGap
+	}
+
+	if w <= 0 {
+		w = 1
+	}
+	if h <= 0 {
+		h = 1
+	}
+	w = min(w, MaxWordImage)
+	h = min(h, MaxWordImage)
+	img := ebiten.NewImage(w, h)
+	text.Draw(img, b.Text, face, (w-rect.Dx())/2-rect.Min.X, -rect.Min.Y+2, b.Color)
+	if romaji != "" {
+		top := rect.Dy() + 4 + romajiGap
+		text.Draw(img, romaji, g.jpFace, (w-rRect.Dx())/2-rRect.Min.X, top-rRect.Min.Y, b.Color)
+	}
+	return img
+}
+
+// boundString is text.BoundString memoized per face, so repeated tokens
+// skip measuring. Keying by face means a reloaded face starts fresh.
+func (g *Game) boundString(face font.Face, s string) image.Rectangle {
+	if g.bounds == nil || len(g.bounds) > maxBoundsCache {
+		g.bounds = make(map[boundsKey]image.Rectangle)
+	}
+	key := boundsKey{face, s}
+	if r, ok := g.bounds[key]; ok {
+		return r
+	}
+	r := text.BoundString(face, s)
+	g.bounds[key] = r
+	return r
+}
+
+type boundsKey struct {
+	face font.Face
+	text string
+}
+
+// Layout returns the logical size: the configured resolution, which
+// Ebiten scales into the window and boxes, or with FitWindow that size
+// grown to the window's aspect.
+func (g *Game) Layout(w, h int) (int, int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if w <= 0 || h <= 0 {
+		return int(g.width), int(g.height) // Minimized
+	}
+	lw, lh := g.baseCfg.Width, g.baseCfg.Height
+	if g.cfg.FitWindow && g.recorder == nil {
+		lw, lh = fitAspect(lw, lh, w, h)
+	}
+	if lw != int(g.width) || lh != int(g.height) {
+		g.resize(lw, lh)
+	}
+	return lw, lh
+}
+
+// fitAspect grows w x h along one side to the aspect of ww x wh.
+func fitAspect(w, h, ww, wh int) (int, int) {
+	if ww*h > wh*w {
+		return int(math.Round(float64(h) * float64(ww) / float64(wh))), h
+	}
+	return w, int(math.Round(float64(w) * float64(wh) / float64(ww)))
+}
+
+// resize changes the logical size and drops what was laid out for the
+// old one. Words in flight keep their positions.
+func (g *Game) resize(w, h int) {
+	logInfo("Layout:", w, "x", h)
+	g.width, g.height = float64(w), float64(h)
+	g.offscreen = nil
+	g.vignette = nil
+	g.gears = nil // Rebuilt by Update
+}
+
+// lerpColor blends every channel, alpha included, rounding to the
+// nearest value and clamping so t outside 0..1 can't wrap.
+func lerpColor(c1, c2 color.RGBA, t float64) color.RGBA {
+	ch := func(a, b uint8) uint8 {
+		v := float64(a) + (float64(b)-float64(a))*t
+		return uint8(math.Max(0, math.Min(255, math.Round(v))))
+	}
+	return color.RGBA{ch(c1.R, c2.R), ch(c1.G, c2.G), ch(c1.B, c2.B), ch(c1.A, c2.A)}
+}
+
+func textToColor(text string) color.RGBA {
+	hash := 0
+	for _, c := range text {
+		hash = int(c) + ((hash << 5) - hash)
+	}
+	h := math.Abs(float64(hash % 360))
+	s := 0.8
+	v := 0.2
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60.0, 2)-1))
+	m := v - c
+	var r, g, b float64
+	switch {
+	case 0 <= h && h < 60:
+		r, g, b = c, x, 0
+	case 60 <= h && h < 120:
+		r, g, b = x, c, 0
+	case 120 <= h && h < 180:
+		r, g, b = 0, c, x
+	case 180 <= h && h < 240:
+		r, g, b = 0, x, c
+	case 240 <= h && h < 300:
+		r, g, b = x, 0, c
+	case 300 <= h && h < 360:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 255,
+	}
+}
+
+// clampOverride limits v to [lo, hi], falling back to def for NaN/Inf.
+func clampOverride(v, lo, hi, def float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return def
+	}
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func mustReadFile(path string) []byte {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,535 @@
+package overlay
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fakeClock is a hand-advanced clock for NewGame.
+type fakeClock struct{ t time.Time }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestGame builds a seeded Game on a fake clock.
+func newTestGame(cfg Config, seed int64) (*Game, *fakeClock) {
+	clock := newFakeClock()
+	return NewGame(cfg, seed, clock.now), clock
+}
+
+// run steps g n frames, advancing the clock one tick per frame.
+func run(t testing.TB, g *Game, clock *fakeClock, n int) {
+	t.Helper()
+	for range n {
+		clock.advance(time.Second / 60)
+		if err := g.Update(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// dumpScene writes the state drawBarrage and drawGeometry render from.
+func dumpScene(g *Game) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "state %s tension %.2f bg %v\n", g.state.CurrentState, g.brain.Tension, g.bgColor)
+	fmt.Fprintf(&sb, "shake %.2f flash %.2f geom %.3f\n", g.shakeAmount, g.flashIntensity, g.geomRotation)
+	for _, b := range g.barrage {
+		c, _ := b.Color.(color.RGBA)
+		fmt.Fprintf(&sb, "%q pos %.2f,%.2f vel %.2f,%.2f rot %.3f scale %.3f,%.3f color %v life %d/%d rest %v\n",
+			b.Text, b.X, b.Y, b.VX, b.VY, b.Rotation, b.Scale, b.ScaleX, c, b.Life, b.MaxLife, b.IsResting)
+	}
+	return sb.String()
+}
+
+// TestGoldenScene replays a scripted conversation with a fixed seed and
+// clock and compares the resulting scene with testdata. Each line is also
+// drawn once so the draw path runs. Run with -update after an intended
+// change.
+func TestGoldenScene(t *testing.T) {
+	cfg := DefaultConfig()
+	g, clock := newTestGame(cfg, 42)
+	useFont(g)
+	screen := ebiten.NewImage(cfg.Width, cfg.Height)
+
+	script := []struct {
+		text  string
+		after int // frames to run after the line
+	}{
+		{"こんにちは", 30},
+		{"今日は天気がいいね", 90},
+		{"でも", 20},
+		{"それは嘘だ", 10},
+		{"絶対に違う", 10},
+		{"えっと", 60},
+		{"なるほど", 120},
+	}
+	var frames []string
+	for _, line := range script {
+		g.SpawnWord(line.text)
+		run(t, g, clock, line.after)
+		g.Draw(screen)
+		frames = append(frames, fmt.Sprintf("after %q\n%s", line.text, dumpScene(g)))
+	}
+	got := strings.Join(frames, "\n")
+
+	path := filepath.Join("testdata", "scene.golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if diff := sceneDiff(string(want), got); diff != "" {
+		t.Errorf("scene differs from %s (run with -update if intended):\n%s", path, diff)
+	}
+
+	// Same seed and clock, same scene
+	g2, clock2 := newTestGame(cfg, 42)
+	for _, line := range script {
+		g2.SpawnWord(line.text)
+		run(t, g2, clock2, line.after)
+	}
+	if dumpScene(g2) != dumpScene(g) {
+		t.Error("two runs with the same seed and clock diverged")
+	}
+}
+
+// sceneTolerance absorbs last-digit rounding, e.g. from fused
+// multiply-add on arm64.
+const sceneTolerance = 0.011
+
+// sceneDiff lists the first few lines that differ between two scene dumps.
+// Numbers match within sceneTolerance, everything else exactly.
+func sceneDiff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var sb strings.Builder
+	shown := 0
+	for i := 0; i < max(len(w), len(g)) && shown < 5; i++ {
+		var a, b string
+		if i < len(w) {
+			a = w[i]
+		}
+		if i < len(g) {
+			b = g[i]
+		}
+		if !sameSceneLine(a, b) {
+			fmt.Fprintf(&sb, "line %d\n  want %s\n  got  %s\n", i+1, a, b)
+			shown++
+		}
+	}
+	return sb.String()
+}
+
+func sameSceneLine(want, got string) bool {
+	split := func(r rune) bool { return r == ' ' || r == ',' || r == '{' || r == '}' }
+	w, g := strings.FieldsFunc(want, split), strings.FieldsFunc(got, split)
+	if len(w) != len(g) {
+		return false
+	}
+	for i := range w {
+		if w[i] == g[i] {
+			continue
+		}
+		a, errA := strconv.ParseFloat(w[i], 64)
+		b, errB := strconv.ParseFloat(g[i], 64)
+		if errA != nil || errB != nil || math.Abs(a-b) > sceneTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// barrageSizes are the live word counts the benchmarks run at.
+var barrageSizes = []int{100, 500, 1000}
+
+// fillBarrage spawns n words that outlive any benchmark run.
+func fillBarrage(g *Game, n int) {
+	for i := range n {
+		g.spawnWordFromConfig(NewWordConfig(fmt.Sprintf("言葉%d", i)))
+	}
+	for i := range g.barrage {
+		g.barrage[i].Life = math.MaxInt32
+		g.barrage[i].MaxLife = math.MaxInt32
+	}
+}
+
+// useFont loads the bundled font, or the bitmap fallback without it.
+func useFont(g *Game) {
+	g.cfg.AssetsDir = filepath.Join("..", "assets")
+	if tt, err := loadFont(g.cfg); err == nil {
+		g.setFaces(tt)
+	} else {
+		g.setFallbackFaces(err)
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	for _, n := range barrageSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			g, clock := newTestGame(DefaultConfig(), 1)
+			fillBarrage(g, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				clock.advance(time.Second / 60)
+				g.Update()
+			}
+		})
+	}
+}
+
+func BenchmarkDrawBarrage(b *testing.B) {
+	for _, n := range barrageSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			g, _ := newTestGame(DefaultConfig(), 1)
+			useFont(g)
+			fillBarrage(g, n)
+			screen := ebiten.NewImage(g.cfg.Width, g.cfg.Height)
+			g.drawBarrage(screen, 0, 0) // Rasterize every word once
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				g.drawBarrage(screen, 0, 0)
+			}
+		})
+	}
+}
+
+// TestDrawBarrageAllocs checks drawBarrage allocates no more per word
+// than the DrawImage call itself: the options are reused, not rebuilt.
+func TestDrawBarrageAllocs(t *testing.T) {
+	for _, invert := range []bool{false, true} {
+		g, _ := newTestGame(DefaultConfig(), 1)
+		useFont(g)
+		if invert {
+			g.cfg.ColorInvertCPU = true
+			g.colorInvert = 1
+		}
+		const n = 50
+		fillBarrage(g, n)
+		screen := ebiten.NewImage(g.cfg.Width, g.cfg.Height)
+		g.drawBarrage(screen, 0, 0)
+
+		img := g.barrage[0].Image
+		op := &ebiten.DrawImageOptions{}
+		cop := &colorm.DrawImageOptions{}
+		m := invertColorM(g.colorInvert)
+		perDraw := testing.AllocsPerRun(20, func() {
+			if invert {
+				colorm.DrawImage(screen, img, m, cop)
+			} else {
+				screen.DrawImage(img, op)
+			}
+		})
+		perWord := testing.AllocsPerRun(20, func() { g.drawBarrage(screen, 0, 0) }) / n
+		if perWord > perDraw+0.1 {
+			t.Errorf("invert %v: %.2f allocs per word, DrawImage alone makes %.2f", invert, perWord, perDraw)
+		}
+	}
+}
+
+func BenchmarkDrawBarrageInverted(b *testing.B) {
+	g, _ := newTestGame(DefaultConfig(), 1)
+	useFont(g)
+	g.cfg.ColorInvertCPU = true
+	g.colorInvert = 1
+	fillBarrage(g, 500)
+	screen := ebiten.NewImage(g.cfg.Width, g.cfg.Height)
+	g.drawBarrage(screen, 0, 0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		g.drawBarrage(screen, 0, 0)
+	}
+}
+
+// TestSpawnRespectsResolution spawns every kind of word at resolutions
+// other than the default; each must start across the configured width
+// and no further than the 100px off-edge entries above and below.
+func TestSpawnRespectsResolution(t *testing.T) {
+	styles := []string{"normal", "conjunction", "impact", "thinking",
+		"silence_dots", "silence_ma", "silence_heavy", "silence_abyss"}
+	for _, res := range [][2]int{{1280, 720}, {1080, 1920}, {3840, 2160}} {
+		cfg := DefaultConfig()
+		cfg.Width, cfg.Height = res[0], res[1]
+		g, clock := newTestGame(cfg, 3)
+		w, h := float64(res[0]), float64(res[1])
+
+		for i := range 200 {
+			if i == 100 {
+				g.SetState("SPLIT")
+			}
+			wc := NewWordConfig(fmt.Sprintf("言葉%d", i))
+			wc.Style = styles[i%len(styles)]
+			clock.advance(time.Duration(i%3) * time.Second) // Alternate speakers
+			n := len(g.barrage)
+			g.spawnWordFromConfig(wc)
+			for _, b := range g.barrage[n:] {
+				if b.X < 0 || b.X > w || b.Y < -100 || b.Y > h+100 {
+					t.Errorf("%dx%d %s: %q spawned at %.0f,%.0f", res[0], res[1], wc.Style, b.Text, b.X, b.Y)
+				}
+			}
+		}
+
+		// Everything that lands, lands on this screen's floor
+		run(t, g, clock, 300)
+		floor := h - g.floorHeight()
+		for _, b := range g.barrage {
+			if b.IsResting && !b.IsSticky && b.Y != floor && !strings.HasPrefix(b.Text, "・") {
+				t.Errorf("%dx%d: %q resting at y %.0f, floor is %.0f", res[0], res[1], b.Text, b.Y, floor)
+			}
+		}
+	}
+}
+
+// TestStyleDefaults spawns each built-in style once and checks the look
+// and motion its table entry gives it.
+func TestStyleDefaults(t *testing.T) {
+	type rng struct{ lo, hi float64 }
+	tests := []struct {
+		style   string
+		scale   rng
+		life    int
+		color   string
+		x, y    rng
+		vx, vy  rng
+		still   bool
+		glitchy bool
+	}{
+		// Default scale is 1..1.5, times 1.5; erupts around the top center
+		{"impact", rng{1.5, 2.25}, 300, "red", rng{760, 1160}, rng{0, 0}, rng{-5, 5}, rng{-5, 5}, false, true},
+		// Glitch shares the entry, but is forced red and boosted 2x
+		{"glitch", rng{1.5, 2.25}, 300, "", rng{760, 1160}, rng{0, 0}, rng{-10, 10}, rng{-10, 10}, false, true},
+		{"silence_dots", rng{1, 1}, 300, "grey_alpha", rng{0, 1920}, rng{0, 1080}, rng{-0.25, 0.25}, rng{-0.25, 0.25}, false, false},
+		// The first turn goes to speaker 1, anchored 80% across and 40%
+		// down; thinking is placed 60px above the anchor
+		{"thinking", rng{1.2, 1.2}, 400, "", rng{1456, 1616}, rng{372, 372}, rng{-0.2, 0.2}, rng{-0.4, -0.4}, true, false},
+		{"silence_ma", rng{3, 3}, 800, "blue_white", rng{960, 960}, rng{360, 360}, rng{0, 0}, rng{0, 0}, false, false},
+		{"silence_heavy", rng{5, 5}, 1000, "dark_grey", rng{100, 1820}, rng{-100, -100}, rng{0, 0}, rng{15, 15}, false, false},
+		{"silence_abyss", rng{7, 7}, 1200, "black", rng{100, 1820}, rng{1180, 1180}, rng{0, 0}, rng{-1, -1}, false, false},
+	}
+	in := func(v float64, r rng) bool { return v >= r.lo-1e-9 && v <= r.hi+1e-9 }
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ShatterChance = 0
+			cfg.StickyChance = 0
+			g, _ := newTestGame(cfg, 5)
+			wc := NewWordConfig("言葉")
+			wc.Style = tt.style
+			g.spawnWordFromConfig(wc)
+			if len(g.barrage) == 0 {
+				t.Fatal("nothing spawned")
+			}
+			b := g.barrage[len(g.barrage)-1]
+
+			if !in(b.Scale, tt.scale) {
+				t.Errorf("scale %.3f, want %v", b.Scale, tt.scale)
+			}
+			if b.MaxLife != tt.life {
+				t.Errorf("life %d, want %d", b.MaxLife, tt.life)
+			}
+			want := ColWhite
+			if tt.glitchy {
+				want = ColRed
+			}
+			if c, ok := g.namedColor(tt.color); ok {
+				want = c
+			}
+			if b.Color != want {
+				t.Errorf("color %v, want %v (%s)", b.Color, want, tt.color)
+			}
+			if !in(b.X, tt.x) || !in(b.Y, tt.y) {
+				t.Errorf("spawned at %.1f,%.1f, want x in %v, y in %v", b.X, b.Y, tt.x, tt.y)
+			}
+			if !in(b.VX, tt.vx) || !in(b.VY, tt.vy) {
+				t.Errorf("velocity %.2f,%.2f, want x in %v, y in %v", b.VX, b.VY, tt.vx, tt.vy)
+			}
+			if tt.still && (b.Rotation != 0 || b.VRotation != 0) {
+				t.Errorf("still style spawned tilted: rot %.3f spin %.3f", b.Rotation, b.VRotation)
+			}
+			if b.IsGlitch != tt.glitchy {
+				t.Errorf("glitch %v, want %v", b.IsGlitch, tt.glitchy)
+			}
+		})
+	}
+}
+
+// TestStyleOverride checks a config entry replaces the built-in one.
+func TestStyleOverride(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Styles["silence_ma"] = StylePhysics{Scale: 2, Life: 120, Color: "cyan", Origin: "top", VY: 3, Gravity: 0.5}
+	g, _ := newTestGame(cfg, 5)
+	wc := NewWordConfig("間")
+	wc.Style = "silence_ma"
+	g.spawnWordFromConfig(wc)
+
+	b := g.barrage[0]
+	cyan, _ := g.namedColor("cyan")
+	if b.Scale != 2 || b.MaxLife != 120 || b.Color != cyan || b.Y != -100 || b.VY != 3 || b.Gravity != 0.5 {
+		t.Errorf("override not applied: scale %v life %d color %v y %v vy %v gravity %v",
+			b.Scale, b.MaxLife, b.Color, b.Y, b.VY, b.Gravity)
+	}
+}
+
+func TestLayoutFitsWindow(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FitWindow = true
+	g, _ := newTestGame(cfg, 1)
+
+	tests := []struct {
+		ww, wh int
+		w, h   int
+	}{
+		{1920, 1080, 1920, 1080},
+		{3840, 2160, 1920, 1080}, // same shape, just bigger
+		{2560, 1080, 2560, 1080}, // ultrawide: wider
+		{1440, 1080, 1920, 1440}, // 4:3 projector: taller
+		{1080, 1920, 1920, 3413}, // portrait
+		{0, 0, 1920, 3413},       // minimized: keep the last size
+	}
+	for _, tt := range tests {
+		w, h := g.Layout(tt.ww, tt.wh)
+		if w != tt.w || h != tt.h {
+			t.Errorf("window %dx%d: layout %dx%d, want %dx%d", tt.ww, tt.wh, w, h, tt.w, tt.h)
+		}
+		if g.width != float64(w) || g.height != float64(h) {
+			t.Errorf("window %dx%d: game is %vx%v, layout %dx%d", tt.ww, tt.wh, g.width, g.height, w, h)
+		}
+	}
+
+	// Back to the configured size, boxed by Ebiten
+	g.cfg.FitWindow = false
+	if w, h := g.Layout(2560, 1080); w != 1920 || h != 1080 {
+		t.Errorf("without fit_window: layout %dx%d, want 1920x1080", w, h)
+	}
+}
+
+func TestLerpColor(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	tests := []struct {
+		name   string
+		c1, c2 color.RGBA
+		t      float64
+		want   color.RGBA
+	}{
+		{"start", black, white, 0, black},
+		{"end", black, white, 1, white},
+		{"midpoint rounds", black, white, 0.5, color.RGBA{128, 128, 128, 255}},
+		{"channel going down", color.RGBA{200, 10, 100, 255}, color.RGBA{100, 10, 0, 255}, 0.25, color.RGBA{175, 10, 75, 255}},
+		{"below 0 clamps", color.RGBA{10, 250, 0, 255}, color.RGBA{250, 10, 0, 255}, -1, color.RGBA{0, 255, 0, 255}},
+		{"above 1 clamps", color.RGBA{10, 250, 0, 255}, color.RGBA{250, 10, 0, 255}, 2, color.RGBA{255, 0, 0, 255}},
+		{"alpha blends", color.RGBA{0, 0, 0, 0}, color.RGBA{0, 0, 0, 200}, 0.5, color.RGBA{0, 0, 0, 100}},
+		{"alpha going down", color.RGBA{50, 50, 50, 255}, color.RGBA{50, 50, 50, 55}, 0.75, color.RGBA{50, 50, 50, 105}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lerpColor(tt.c1, tt.c2, tt.t); got != tt.want {
+				t.Errorf("lerpColor(%v, %v, %v) = %v, want %v", tt.c1, tt.c2, tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+// finiteWords fails if any word's position, velocity or scale is NaN
+// or infinite.
+func finiteWords(t *testing.T, g *Game) {
+	t.Helper()
+	for _, b := range g.barrage {
+		for _, v := range []float64{b.X, b.Y, b.VX, b.VY, b.Scale, b.ScaleX} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Fatalf("%q: non-finite word %+v", b.Text, b)
+			}
+		}
+	}
+}
+
+// FuzzSpawnWord feeds arbitrary text and overrides through the spawn
+// path: nothing may panic, and every word must stay finite as it flies.
+func FuzzSpawnWord(f *testing.F) {
+	f.Add("こんにちは", "normal", 0.0, 0.0, 1.0, 1.0, 1.0)
+	f.Add("嘘だ", "impact", 3.14, -20.0, -1.0, 5.0, -1.0)
+	f.Add("でも", "conjunction", math.Inf(1), math.NaN(), math.Inf(-1), math.NaN(), math.MaxFloat64)
+	f.Add("", "silence_abyss", -1e308, 1e308, 0.0, -0.0, 1e-308)
+	f.Add("\x00\xff", "shatter", 0.0, 0.0, 1.0, 1.0, 1.0)
+
+	f.Fuzz(func(t *testing.T, text, style string, rot, vy, vyMult, scale, scaleX float64) {
+		g, clock := newTestGame(DefaultConfig(), 1)
+		g.spawnText(text, false)
+
+		wc := NewWordConfig(text)
+		wc.Style = style
+		wc.Rot, wc.VY, wc.VYMult, wc.Scale, wc.ScaleX = rot, vy, vyMult, scale, scaleX
+		g.spawnWordFromConfig(wc)
+		finiteWords(t, g)
+
+		run(t, g, clock, 30)
+		finiteWords(t, g)
+	})
+}
+
+// TestSpawnWhileDrawing calls the public API from another goroutine while
+// frames are updated and drawn, as a host app would. Run with -race.
+func TestSpawnWhileDrawing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GrainIntensity = 0.1
+	cfg.VignetteStrength = 0.5
+	cfg.Watermarks = []string{"脳内劇場"}
+	g, clock := newTestGame(cfg, 1)
+	useFont(g)
+	screen := ebiten.NewImage(g.cfg.Width, g.cfg.Height)
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		words := []string{"こんにちは", "嘘だ", "でも", "えっと", "なるほど"}
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			g.SpawnWord(words[i%len(words)])
+			if i%50 == 25 {
+				g.SetState("SPLIT")
+			}
+			runtime.Gosched()
+		}
+	}()
+	for range 60 {
+		g.mu.Lock()
+		clock.advance(time.Second / 60)
+		g.mu.Unlock()
+		g.Update()
+		g.Draw(screen)
+	}
+	close(stop)
+	<-done
+}
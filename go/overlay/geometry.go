@@ -0,0 +1,94 @@
+package overlay
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	waveformSize   = 2048 // samples kept for the oscilloscope (~46ms)
+	waveformPoints = 512  // vertices in the drawn trace
+)
+
+// drawScope plots the latest mic samples as a horizontal oscilloscope
+// trace across the middle of the screen.
+func (g *Game) drawScope(screen *ebiten.Image, dx, dy float64, flipY float32) {
+	if g.speech == nil {
+		return
+	}
+	g.waveform = g.speech.Waveform(g.waveform)
+	if len(g.waveform) < 2 {
+		return
+	}
+
+	c, ok := g.namedColor(g.cfg.ScopeColor)
+	if !ok {
+		c = ColWhite
+	}
+	c = g.monoColor(c)
+	amp := float32(g.cfg.ScopeAmplitude*g.height/2) * flipY
+	cy := float32(g.height/2 + dy)
+	step := max(len(g.waveform)/waveformPoints, 1)
+
+	var path vector.Path
+	for i := 0; i < len(g.waveform); i += step {
+		x := float32(dx) + float32(i)/float32(len(g.waveform)-1)*float32(g.width)
+		y := cy - g.waveform[i]*amp
+		if i == 0 {
+			path.MoveTo(x, y)
+		} else {
+			path.LineTo(x, y)
+		}
+	}
+
+	op := &vector.DrawPathOptions{AntiAlias: true}
+	op.ColorScale.ScaleWithColor(color.RGBA{c.R, c.G, c.B, 255})
+	vector.StrokePath(screen, &path, &vector.StrokeOptions{Width: 3, LineJoin: vector.LineJoinRound}, op)
+}
+
+// drawSpectrum arranges SpectrumBars bars around a center circle. With
+// only three bands, energy is interpolated bass -> mid -> treble over each
+// half so the ring stays symmetric. During SPLIT the bars fracture.
+func (g *Game) drawSpectrum(screen *ebiten.Image, cx, cy float32, bands Bands, flipY float32, split bool) {
+	n := g.cfg.SpectrumBars
+	if n <= 0 {
+		return
+	}
+
+	g.mu.RLock()
+	spin := g.clock * g.cfg.SpectrumSpin
+	g.mu.RUnlock()
+
+	inner := float32(180 + bands[BandBass]*120)
+	col := ColWhite
+	if split {
+		col = ColRed
+	}
+	width := float32(max(2*math.Pi*float64(inner)/float64(n)*0.6, 1))
+
+	for i := 0; i < n; i++ {
+		// Bass at the first bar, treble half way round, back to bass
+		t := float64(i) / float64(n) * 2
+		if t > 1 {
+			t = 2 - t
+		}
+		pos := t * float64(BandTreble)
+		lo := int(pos)
+		hi := min(lo+1, BandTreble)
+		e := bands[lo] + (bands[hi]-bands[lo])*(pos-float64(lo))
+
+		theta := spin + float64(i)/float64(n)*2*math.Pi
+		r0 := inner
+		length := float32(10 + e*500)
+		if split {
+			theta += (g.drawRng.Float64() - 0.5) * 0.15
+			r0 += float32(g.drawRng.Float64() * 60)
+		}
+
+		cos, sin := float32(math.Cos(theta)), float32(math.Sin(theta))*flipY
+		vector.StrokeLine(screen, cx+cos*r0, cy+sin*r0, cx+cos*(r0+length), cy+sin*(r0+length), width, col, true)
+	}
+}
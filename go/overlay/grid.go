@@ -0,0 +1,51 @@
+package overlay
+
+import "math"
+
+// spatialGrid buckets barrage indices by screen cell so area effects
+// only visit words near them instead of the whole barrage.
+type spatialGrid struct {
+	cell       float64
+	cols, rows int
+	cells      [][]int
+}
+
+// rebuild re-buckets words, reusing the cell slices between frames.
+// Words off screen land in the nearest edge cell.
+func (s *spatialGrid) rebuild(words []BarrageWord, cell, width, height float64) {
+	if cell <= 0 {
+		cell = 200
+	}
+	cols := int(math.Ceil(width / cell))
+	rows := int(math.Ceil(height / cell))
+	if s.cell != cell || len(s.cells) != cols*rows {
+		s.cell, s.cols, s.rows = cell, cols, rows
+		s.cells = make([][]int, cols*rows)
+	}
+	for i := range s.cells {
+		s.cells[i] = s.cells[i][:0]
+	}
+	for i, w := range words {
+		c := s.clamp(int(w.X/cell), s.cols) + s.clamp(int(w.Y/cell), s.rows)*s.cols
+		s.cells[c] = append(s.cells[c], i)
+	}
+}
+
+// query calls fn with the index of every word bucketed within the cells
+// overlapping the square of half-size r around (x, y). Callers still
+// check the exact distance.
+func (s *spatialGrid) query(x, y, r float64, fn func(i int)) {
+	x0, x1 := s.clamp(int((x-r)/s.cell), s.cols), s.clamp(int((x+r)/s.cell), s.cols)
+	y0, y1 := s.clamp(int((y-r)/s.cell), s.rows), s.clamp(int((y+r)/s.cell), s.rows)
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			for _, i := range s.cells[cx+cy*s.cols] {
+				fn(i)
+			}
+		}
+	}
+}
+
+func (s *spatialGrid) clamp(c, n int) int {
+	return max(0, min(c, n-1))
+}
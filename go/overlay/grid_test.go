@@ -0,0 +1,116 @@
+package overlay
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// scatter places n words at random, some of them off screen.
+func scatter(n int, w, h float64, seed int64) []BarrageWord {
+	rng := rand.New(rand.NewSource(seed))
+	words := make([]BarrageWord, n)
+	for i := range words {
+		words[i].X = rng.Float64()*(w+400) - 200
+		words[i].Y = rng.Float64()*(h+400) - 200
+	}
+	return words
+}
+
+// within lists the words no farther than r from (x, y), by brute force.
+func within(words []BarrageWord, x, y, r float64) []int {
+	var out []int
+	for i, b := range words {
+		if math.Hypot(b.X-x, b.Y-y) <= r {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func TestGridQueryMatchesBruteForce(t *testing.T) {
+	const w, h = 1920, 1080
+	words := scatter(2000, w, h, 1)
+	var grid spatialGrid
+	for _, cell := range []float64{0, 50, 200, 700} {
+		grid.rebuild(words, cell, w, h)
+		for _, q := range []struct{ x, y, r float64 }{
+			{960, 540, 150},
+			{0, 0, 300},
+			{w, h, 80},
+			{-150, 500, 120}, // off screen
+			{500, 300, 0},
+			{960, 540, 2000}, // covers everything
+		} {
+			var got []int
+			grid.query(q.x, q.y, q.r, func(i int) {
+				if math.Hypot(words[i].X-q.x, words[i].Y-q.y) <= q.r {
+					got = append(got, i)
+				}
+			})
+			slices.Sort(got)
+			if want := within(words, q.x, q.y, q.r); !slices.Equal(got, want) {
+				t.Errorf("cell %v query %+v: got %d words, want %d", cell, q, len(got), len(want))
+			}
+		}
+	}
+}
+
+// BenchmarkGridNeighbours finds each word's neighbours within 100px,
+// the query collision needs, through the grid and by scanning all pairs.
+func BenchmarkGridNeighbours(b *testing.B) {
+	const w, h, r = 1920, 1080, 100
+	for _, n := range []int{100, 1000, 5000} {
+		words := scatter(n, w, h, 1)
+		b.Run(fmt.Sprintf("grid/%d", n), func(b *testing.B) {
+			var grid spatialGrid
+			b.ReportAllocs()
+			for range b.N {
+				found := 0
+				grid.rebuild(words, 200, w, h)
+				for _, p := range words {
+					grid.query(p.X, p.Y, r, func(i int) {
+						if math.Hypot(words[i].X-p.X, words[i].Y-p.Y) <= r {
+							found++
+						}
+					})
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("all_pairs/%d", n), func(b *testing.B) {
+			for range b.N {
+				found := 0
+				for _, p := range words {
+					for _, q := range words {
+						if math.Hypot(q.X-p.X, q.Y-p.Y) <= r {
+							found++
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAreaForces runs a frame of pointer and well forces over the
+// barrage at growing sizes.
+func BenchmarkAreaForces(b *testing.B) {
+	for _, n := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cfg := DefaultConfig()
+			cfg.WellStrength = 1
+			cfg.WellAtSpeaker = true
+			cfg.WellAtSplitCenter = true
+			g, _ := newTestGame(cfg, 1)
+			g.state.CurrentState = "SPLIT"
+			g.barrage = scatter(n, g.width, g.height, 1)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				g.applyAreaForces()
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package overlay
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// updateHotkeys handles the operator function keys.
+// They run even while paused.
+func (g *Game) updateHotkeys() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyPause) || inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		g.togglePause()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.showDebug = !g.showDebug
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF8) {
+		g.toggleMute()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		g.transcript.visible = !g.transcript.visible
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF6) {
+		g.nextWatermark()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF7) {
+		g.reloadFont()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		setFullscreen(!ebiten.IsFullscreen())
+	}
+	// Ctrl so the digits stay free for the type buffer. Ctrl+0 is the
+	// startup look, Ctrl+1.. the presets
+	if ebiten.IsKeyPressed(ebiten.KeyControl) {
+		if inpututil.IsKeyJustPressed(ebiten.KeyDigit0) {
+			g.applyPreset(-1)
+		}
+		for k := ebiten.KeyDigit1; k <= ebiten.KeyDigit9; k++ {
+			if inpututil.IsKeyJustPressed(k) {
+				g.applyPreset(int(k - ebiten.KeyDigit1))
+			}
+		}
+	}
+}
+
+// toggleMute stops the show reacting to the microphone (and Discord)
+// without closing the audio device. The meter drops straight to zero.
+func (g *Game) toggleMute() {
+	if g.speech == nil {
+		return
+	}
+	muted := !g.speech.Muted.Load()
+	g.speech.Muted.Store(muted)
+	if muted {
+		g.micVolume = 0
+		g.bands = Bands{}
+	}
+	logInfo("Mic muted:", muted)
+}
+
+// setFullscreen switches fullscreen and hides the cursor for clean projection.
+// Layout keeps the logical size (or fits it, with FitWindow), so nothing stretches.
+func setFullscreen(on bool) {
+	ebiten.SetFullscreen(on)
+	if on {
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	} else {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	}
+}
+
+// updateTyping collects typed text into the operator buffer.
+// Enter spawns it through the Brain, Backspace deletes, Escape clears.
+func (g *Game) updateTyping() {
+	g.typeBuffer = ebiten.AppendInputChars(g.typeBuffer)
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(g.typeBuffer) > 0 {
+		g.typeBuffer = g.typeBuffer[:len(g.typeBuffer)-1]
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.typeBuffer = g.typeBuffer[:0]
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) {
+		if len(g.typeBuffer) > 0 {
+			g.spawnText(string(g.typeBuffer), false)
+			g.typeBuffer = g.typeBuffer[:0]
+		}
+	}
+}
+
+// drawTypeBuffer shows the pending operator text along the bottom edge.
+func (g *Game) drawTypeBuffer(screen *ebiten.Image) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.typeBuffer) == 0 || g.jpFace == nil {
+		return
+	}
+	text.Draw(screen, "> "+string(g.typeBuffer)+"_", g.jpFace, 40, int(g.height)-40, color.RGBA{240, 240, 240, 200})
+}
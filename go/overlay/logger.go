@@ -0,0 +1,50 @@
+package overlay
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel filters what reaches the log. Messages below the current
+// level are dropped, so a show can run at "warn" and only see trouble.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var logLevel = LevelInfo
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// ParseLogLevel accepts debug / info / warn / error (any case).
+func ParseLogLevel(s string) (LogLevel, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return LogLevel(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+func logAt(level LogLevel, v ...any) {
+	if level < logLevel {
+		return
+	}
+	log.Println(append([]any{levelNames[level]}, v...)...)
+}
+
+func logDebug(v ...any) { logAt(LevelDebug, v...) }
+func logInfo(v ...any)  { logAt(LevelInfo, v...) }
+func logWarn(v ...any)  { logAt(LevelWarn, v...) }
+func logError(v ...any) { logAt(LevelError, v...) }
+
+func logInfof(format string, v ...any) { logAt(LevelInfo, fmt.Sprintf(format, v...)) }
+
+// Warn logs at warn level through the package logger, for callers such
+// as the command line that run before or around a Game.
+func Warn(v ...any) { logWarn(v...) }
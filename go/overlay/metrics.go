@@ -0,0 +1,88 @@
+package overlay
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics exposes session counters for monitoring an unattended exhibit.
+// A nil *metrics is valid and records nothing.
+type metrics struct {
+	wordsTotal    prometheus.Counter
+	barrage       prometheus.Gauge
+	tension       prometheus.Gauge
+	splitSeconds  prometheus.Counter
+	droppedFrames prometheus.CounterFunc
+	droppedText   prometheus.CounterFunc
+}
+
+// startMetrics serves /metrics on addr in the background.
+func startMetrics(addr string, speech *SpeechEngine) *metrics {
+	reg := prometheus.NewRegistry()
+	m := &metrics{
+		wordsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "overlay_recognized_words_total",
+			Help: "Utterances received from the recognizer.",
+		}),
+		barrage: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "overlay_barrage_words",
+			Help: "Words currently on screen.",
+		}),
+		tension: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "overlay_tension",
+			Help: "Current Brain tension.",
+		}),
+		splitSeconds: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "overlay_split_seconds_total",
+			Help: "Time spent in the SPLIT state.",
+		}),
+		droppedFrames: prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "overlay_dropped_audio_frames_total",
+			Help: "Audio buffers whose level readings were dropped because the game fell behind.",
+		}, func() float64 {
+			if speech == nil {
+				return 0
+			}
+			return float64(speech.DroppedVol.Load())
+		}),
+		droppedText: prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "overlay_dropped_utterances_total",
+			Help: "Recognized utterances dropped because the game fell behind.",
+		}, func() float64 {
+			if speech == nil {
+				return 0
+			}
+			return float64(speech.DroppedText.Load())
+		}),
+	}
+	reg.MustRegister(m.wordsTotal, m.barrage, m.tension, m.splitSeconds, m.droppedFrames, m.droppedText)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logError("Metrics Error:", err)
+		}
+	}()
+	return m
+}
+
+func (m *metrics) recognized() {
+	if m != nil {
+		m.wordsTotal.Inc()
+	}
+}
+
+// observe samples the per-tick gauges. dt is in seconds.
+func (m *metrics) observe(g *Game, dt float64) {
+	if m == nil {
+		return
+	}
+	m.barrage.Set(float64(len(g.barrage)))
+	m.tension.Set(g.brain.Tension)
+	if g.state.CurrentState == "SPLIT" {
+		m.splitSeconds.Add(dt)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build ndi
+
+package overlay
+
+/*
+#cgo linux darwin LDFLAGS: -lndi
+#cgo windows LDFLAGS: -lProcessing.NDI.Lib.x64
+#include <stdlib.h>
+#include <Processing.NDI.Lib.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// ndiSender wraps one NDI send instance. Built with -tags ndi against the
+// NDI SDK; without the tag ndi_stub.go reports it as unavailable.
+type ndiSender struct {
+	inst C.NDIlib_send_instance_t
+	fps  int
+}
+
+func newNDISender(name string, fps int) (*ndiSender, error) {
+	if !C.NDIlib_initialize() {
+		return nil, errors.New("NDI runtime not available (CPU unsupported or library missing)")
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	desc := C.NDIlib_send_create_t{
+		p_ndi_name:  cname,
+		clock_video: true, // send paces itself to fps
+	}
+	inst := C.NDIlib_send_create(&desc)
+	if inst == nil {
+		return nil, errors.New("NDI sender could not be created")
+	}
+	return &ndiSender{inst: inst, fps: fps}, nil
+}
+
+// send pushes one frame as straight-alpha RGBA. It blocks to keep the
+// configured frame rate, so call it off the game loop.
+func (s *ndiSender) send(pix []byte, w, h int) {
+	unpremultiply(pix)
+	frame := C.NDIlib_video_frame_v2_t{
+		xres:                 C.int(w),
+		yres:                 C.int(h),
+		FourCC:               C.NDIlib_FourCC_type_RGBA,
+		frame_rate_N:         C.int(s.fps),
+		frame_rate_D:         1,
+		picture_aspect_ratio: C.float(float64(w) / float64(h)),
+		frame_format_type:    C.NDIlib_frame_format_type_progressive,
+		timecode:             C.NDIlib_send_timecode_synthesize,
+		p_data:               (*C.uint8_t)(unsafe.Pointer(&pix[0])),
+	}
+	*(*C.int)(unsafe.Pointer(&frame.anon0)) = C.int(w * 4) // line_stride_in_bytes
+	C.NDIlib_send_send_video_v2(s.inst, &frame)
+}
+
+func (s *ndiSender) close() {
+	C.NDIlib_send_destroy(s.inst)
+	C.NDIlib_destroy()
+}
@@ -0,0 +1,15 @@
+//go:build !ndi
+
+package overlay
+
+import "errors"
+
+type ndiSender struct{}
+
+func newNDISender(name string, fps int) (*ndiSender, error) {
+	return nil, errors.New("built without NDI support (rebuild with -tags ndi and the NDI SDK)")
+}
+
+func (s *ndiSender) send(pix []byte, w, h int) {}
+
+func (s *ndiSender) close() {}
@@ -0,0 +1,119 @@
+package overlay
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// videoSink is where a videoOutput delivers frames: premultiplied
+// RGBA, w*h*4 bytes, on the output's own goroutine.
+type videoSink interface {
+	send(pix []byte, w, h int)
+	close()
+}
+
+// videoOutput feeds rendered frames to a sink (NDI, virtual camera).
+// Draw scales the frame into its own image and reads it back; a
+// goroutine hands it on so the game loop never waits on the sink.
+type videoOutput struct {
+	sink  videoSink
+	w, h  int
+	every time.Duration
+	last  time.Time
+	frame *ebiten.Image
+	free  chan []byte // Recycled pixel buffers
+	ready chan []byte
+	done  chan struct{}
+}
+
+// startNDI opens the sender named cfg.NDIName.
+func startNDI(cfg Config) (*videoOutput, error) {
+	w, h := cfg.NDIWidth, cfg.NDIHeight
+	if w <= 0 || h <= 0 {
+		w, h = cfg.Width, cfg.Height
+	}
+	fps := cfg.NDIFPS
+	if fps <= 0 {
+		fps = cfg.TPS
+	}
+	sender, err := newNDISender(cfg.NDIName, fps)
+	if err != nil {
+		return nil, err
+	}
+	logInfo("NDI:", cfg.NDIName)
+	return startVideoOutput(sender, w, h, fps), nil
+}
+
+// startVideoOutput delivers frames to sink at w x h, fps times a second.
+func startVideoOutput(sink videoSink, w, h, fps int) *videoOutput {
+	o := &videoOutput{
+		sink:  sink,
+		w:     w,
+		h:     h,
+		every: time.Second / time.Duration(fps),
+		frame: ebiten.NewImage(w, h),
+		free:  make(chan []byte, 2),
+		ready: make(chan []byte, 1),
+		done:  make(chan struct{}),
+	}
+	for range cap(o.free) {
+		o.free <- make([]byte, 4*w*h)
+	}
+	go o.run()
+	logInfo("Video Output:", w, "x", h, "@", fps)
+	return o
+}
+
+// capture grabs screen if a frame is due and a buffer is free.
+// A nil *videoOutput does nothing.
+func (o *videoOutput) capture(screen *ebiten.Image) {
+	if o == nil || time.Since(o.last) < o.every {
+		return
+	}
+	var pix []byte
+	select {
+	case pix = <-o.free:
+	default:
+		return // sender still busy, skip this frame
+	}
+	o.last = time.Now()
+
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	op := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+	op.GeoM.Scale(float64(o.w)/float64(sw), float64(o.h)/float64(sh))
+	op.Blend = ebiten.BlendCopy
+	o.frame.DrawImage(screen, op)
+	o.frame.ReadPixels(pix)
+	o.ready <- pix
+}
+
+func (o *videoOutput) run() {
+	defer close(o.done)
+	for pix := range o.ready {
+		o.sink.send(pix, o.w, o.h)
+		o.free <- pix
+	}
+}
+
+func (o *videoOutput) close() {
+	if o == nil {
+		return
+	}
+	close(o.ready)
+	<-o.done
+	o.sink.close()
+}
+
+// unpremultiply converts Ebiten's premultiplied RGBA to straight alpha.
+func unpremultiply(pix []byte) {
+	for i := 0; i < len(pix); i += 4 {
+		a := uint32(pix[i+3])
+		if a == 0 || a == 255 {
+			continue
+		}
+		pix[i] = uint8(uint32(pix[i]) * 255 / a)
+		pix[i+1] = uint8(uint32(pix[i+1]) * 255 / a)
+		pix[i+2] = uint8(uint32(pix[i+2]) * 255 / a)
+	}
+}
@@ -0,0 +1,94 @@
+package overlay
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Particle is a short-lived dot thrown out by an impact word.
+type Particle struct {
+	X, Y, VX, VY float64
+	Life         int
+	MaxLife      int
+	Color        color.RGBA
+}
+
+// burstParticles throws ParticleCount dots out radially from (x, y).
+// The slice is capped at MaxParticles and reused, so rapid impacts
+// drop the oldest dots instead of growing without bound.
+func (g *Game) burstParticles(x, y float64) {
+	col, ok := g.namedColor(g.cfg.ParticleColor)
+	if !ok {
+		col = ColRed
+	}
+	col = g.monoColor(col)
+	life := int(0.5 * 60 / g.tickScale())
+
+	for i := 0; i < g.cfg.ParticleCount; i++ {
+		theta := g.rng.Float64() * 2 * math.Pi
+		speed := g.cfg.ParticleSpeed * (0.5 + g.rng.Float64()*0.5)
+		g.addParticle(Particle{
+			X: x, Y: y,
+			VX:   math.Cos(theta) * speed,
+			VY:   math.Sin(theta) * speed,
+			Life: life, MaxLife: life,
+			Color: col,
+		})
+	}
+}
+
+// driftParticles scatters n slow motes across the screen, rising gently.
+func (g *Game) driftParticles(n int, col color.RGBA) {
+	life := int(3 * 60 / g.tickScale())
+	for i := 0; i < n; i++ {
+		g.addParticle(Particle{
+			X:  g.rng.Float64() * g.width,
+			Y:  g.rng.Float64() * g.height,
+			VX: (g.rng.Float64() - 0.5) * 0.5,
+			VY: -0.5 - g.rng.Float64(),
+			// Spread the fade so they don't vanish together
+			Life: life/2 + g.rng.Intn(life/2+1), MaxLife: life,
+			Color: col,
+		})
+	}
+}
+
+// addParticle appends p, recycling the oldest slot once MaxParticles is reached.
+func (g *Game) addParticle(p Particle) {
+	if len(g.particles) < g.cfg.MaxParticles {
+		g.particles = append(g.particles, p)
+	} else if len(g.particles) > 0 {
+		g.particles[g.particleNext%len(g.particles)] = p
+		g.particleNext++
+	}
+}
+
+func (g *Game) updateParticles() {
+	dt := g.tickScale()
+	alive := g.particles[:0]
+	for _, p := range g.particles {
+		p.X += p.VX * dt
+		p.Y += p.VY * dt
+		p.VX *= math.Pow(0.9, dt)
+		p.VY *= math.Pow(0.9, dt)
+		p.Life--
+		if p.Life > 0 {
+			alive = append(alive, p)
+		}
+	}
+	g.particles = alive
+}
+
+func (g *Game) drawParticles(screen *ebiten.Image, dx, dy float64) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, p := range g.particles {
+		c := p.Color
+		c.A = uint8(float64(c.A) * float64(p.Life) / float64(p.MaxLife))
+		vector.DrawFilledRect(screen, float32(p.X+dx)-3, float32(p.Y+dy)-3, 6, 6, c, false)
+	}
+}
@@ -0,0 +1,178 @@
+package overlay
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Particles that end a chunk when splitting a long phrase.
+var splitParticles = map[string]bool{
+	"は": true, "が": true, "を": true, "に": true, "で": true,
+	"と": true, "も": true, "へ": true, "から": true, "まで": true,
+}
+
+// pendingWord is a chunk of a split phrase waiting for its turn to spawn.
+type pendingWord struct {
+	cfg WordConfig
+	due time.Time
+}
+
+// blankText reports whether s has nothing to show once spaces and
+// punctuation are ignored.
+func blankText(s string) bool {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) == ""
+}
+
+// splitPhrase breaks a recognized phrase into chunks of at most maxRunes.
+// Vosk separates words with spaces, so whole words are packed greedily and
+// a chunk also ends after a particle. maxRunes <= 0 disables splitting.
+func splitPhrase(text string, maxRunes int) []string {
+	if maxRunes <= 0 || utf8.RuneCountInString(text) <= maxRunes {
+		return []string{text}
+	}
+
+	var chunks []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			chunks = append(chunks, string(cur))
+			cur = cur[:0]
+		}
+	}
+
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		if len(cur)+len(runes) > maxRunes {
+			flush()
+		}
+		// A single over-long word is cut by length
+		for len(runes) > maxRunes {
+			chunks = append(chunks, string(runes[:maxRunes]))
+			runes = runes[maxRunes:]
+		}
+		cur = append(cur, runes...)
+		if splitParticles[word] {
+			flush()
+		}
+	}
+	flush()
+	return chunks
+}
+
+// spawnText runs recognized or typed text through the Brain and spawns it.
+// Long phrases are split and the follow-up chunks are queued as a
+// staggered burst from the same speaker. paced applies the spawn limiter,
+// which is for recognized speech only.
+func (g *Game) spawnText(text string, paced bool) {
+	now := g.brain.Now()
+	if blankText(text) {
+		return
+	}
+	for i, chunk := range splitPhrase(text, g.cfg.SplitMaxRunes) {
+		if blankText(chunk) {
+			continue
+		}
+		cfg := g.brain.ProcessText(chunk)
+		if paced && !g.allowSpawn(cfg) {
+			logDebug("Rate limited:", chunk)
+			continue
+		}
+		if i == 0 {
+			g.spawnWordFromConfig(cfg)
+			continue
+		}
+		cfg.Continuation = true
+		delay := time.Duration(float64(i) * g.cfg.SplitStagger * float64(time.Second))
+		g.pending = append(g.pending, pendingWord{cfg: cfg, due: now.Add(delay)})
+	}
+}
+
+// Styles the spawn limiter never drops.
+var keyStyles = map[string]bool{
+	"impact": true, "conjunction": true, "highlight": true,
+	"invert_v": true, "invert_h": true, "invert_c": true,
+}
+
+// allowSpawn paces spoken words with a token bucket holding SpawnBurst
+// words, refilled at SpawnRate per second. The Brain has already heard
+// a dropped word, so tension still counts it. Key styles always pass.
+func (g *Game) allowSpawn(cfg WordConfig) bool {
+	if g.cfg.SpawnRate <= 0 {
+		return true
+	}
+	now := g.brain.Now()
+	burst := math.Max(g.cfg.SpawnBurst, 1)
+	if g.spawnRefill.IsZero() {
+		g.spawnTokens = burst
+	} else {
+		g.spawnTokens = math.Min(burst, g.spawnTokens+now.Sub(g.spawnRefill).Seconds()*g.cfg.SpawnRate)
+	}
+	g.spawnRefill = now
+
+	if g.spawnTokens >= 1 {
+		g.spawnTokens--
+		return true
+	}
+	return keyStyles[cfg.Style]
+}
+
+// hear spawns a recognized utterance. An exact repeat inside
+// DedupeWindow is dropped; results under MinConfidence are dropped, or
+// shown as a faint ghost that leaves the Brain untouched.
+func (g *Game) hear(u Utterance) {
+	now := g.brain.Now()
+	last := g.lastHeard
+	g.lastHeard = heardResult{u.Text, u.Speaker, now}
+	if u.Text == last.text && u.Speaker == last.speaker &&
+		now.Sub(last.at).Seconds() < g.cfg.DedupeWindow {
+		logDebug("Dropped (duplicate):", u.Text)
+		return
+	}
+
+	// A known speaker overrides the local turn guess
+	g.heardSpeaker = u.Speaker
+	defer func() { g.heardSpeaker = -1 }()
+
+	if u.Conf >= g.cfg.MinConfidence {
+		g.spawnText(u.Text, true)
+	} else if g.cfg.LowConfidence == "ghost" {
+		cfg := NewWordConfig(u.Text)
+		cfg.Color = "grey_alpha"
+		g.spawnWordFromConfig(cfg)
+	} else {
+		logDebug("Dropped (low confidence):", u.Text)
+		return
+	}
+	// Tagged after spawning, which settles the speaker
+	g.transcript.add(u.Text, g.currentSpeaker, g.cfg.TranscriptLines)
+}
+
+// heardResult is the last utterance hear saw, for dropping doubles.
+type heardResult struct {
+	text    string
+	speaker int
+	at      time.Time
+}
+
+// spawnPending spawns queued chunks whose time has come.
+func (g *Game) spawnPending() {
+	if len(g.pending) == 0 {
+		return
+	}
+
+	now := g.brain.Now()
+	rest := g.pending[:0]
+	for _, p := range g.pending {
+		if now.Before(p.due) {
+			rest = append(rest, p)
+			continue
+		}
+		g.spawnWordFromConfig(p.cfg)
+	}
+	g.pending = rest
+}
@@ -0,0 +1,109 @@
+package overlay
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLimiterKeepsKeyWords hears speech far faster than SpawnRate allows:
+// the filler is thinned out, but every impact and conjunction still spawns.
+func TestLimiterKeepsKeyWords(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SpawnRate = 2
+	cfg.SpawnBurst = 3
+	g, clock := newTestGame(cfg, 1)
+	events := g.SpawnEvents(1000)
+
+	said := map[string]bool{}
+	for i := range 60 {
+		text := fmt.Sprintf("言葉%d", i)
+		switch i % 6 {
+		case 2:
+			text = fmt.Sprintf("嘘%d", i)
+		case 5:
+			text = fmt.Sprintf("でも%d", i)
+		}
+		said[text] = true
+		g.hear(Utterance{Text: text, Conf: 1, Speaker: -1})
+		clock.advance(50 * time.Millisecond)
+	}
+
+	spawned := map[string]string{}
+	for len(events) > 0 {
+		e := <-events
+		spawned[e.Text] = e.Style
+	}
+	filler := 0
+	for text := range said {
+		style := g.brain.AnalyzeSemantics(text).Style
+		if keyStyles[style] {
+			if _, ok := spawned[text]; !ok {
+				t.Errorf("%s word %q was dropped", style, text)
+			}
+		} else if _, ok := spawned[text]; ok {
+			filler++
+		}
+	}
+
+	// 3 seconds of talk: the burst plus 2 a second, less what key words used up
+	if limit := int(cfg.SpawnBurst + 3*cfg.SpawnRate); filler > limit || filler == 0 {
+		t.Errorf("%d of 40 filler words spawned, want 1..%d", filler, limit)
+	}
+
+	// Dropped words were still heard
+	unseen := 0
+	for _, w := range g.brain.RecentWords {
+		if _, ok := spawned[w]; !ok {
+			unseen++
+		}
+	}
+	if unseen == 0 {
+		t.Error("no dropped word reached the Brain")
+	}
+}
+
+// TestLimiterOnlyPacesSpeech checks SpawnWord calls are never dropped by
+// the limiter, however fast they come.
+func TestLimiterOnlyPacesSpeech(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SpawnRate = 1
+	cfg.SpawnBurst = 1
+	g, clock := newTestGame(cfg, 1)
+	events := g.SpawnEvents(100)
+
+	for i := range 10 {
+		g.SpawnWord(fmt.Sprintf("言葉%d", i))
+		clock.advance(10 * time.Millisecond)
+	}
+	if n := len(events); n != 10 {
+		t.Errorf("%d of 10 words spawned", n)
+	}
+}
+
+// TestTranscriptSkipsDroppedResults checks only results that spawn, as
+// words or ghosts, reach the transcript.
+func TestTranscriptSkipsDroppedResults(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinConfidence = 0.5
+	g, clock := newTestGame(cfg, 1)
+
+	hear := func(text string, conf float64) {
+		g.hear(Utterance{Text: text, Conf: conf, Speaker: -1})
+		clock.advance(time.Second)
+	}
+	hear("聞こえた", 0.9)
+	hear("ぼそぼそ", 0.2) // dropped
+	g.cfg.LowConfidence = "ghost"
+	hear("かすかに", 0.2)
+
+	var got []string
+	for _, l := range g.transcript.lines {
+		got = append(got, l[strings.Index(l, ": ")+2:])
+	}
+	if want := []string{"聞こえた", "かすかに"}; !slices.Equal(got, want) {
+		t.Errorf("transcript %q, want %q", got, want)
+	}
+}
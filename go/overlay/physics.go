@@ -0,0 +1,455 @@
+package overlay
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// gravityWell is an attractor that pulls nearby flying words toward it.
+type gravityWell struct {
+	X, Y float64
+}
+
+// gravityWells returns the attractors active this tick.
+func (g *Game) gravityWells() []gravityWell {
+	if g.cfg.WellStrength == 0 {
+		return nil
+	}
+
+	var wells []gravityWell
+	if g.cfg.WellAtSpeaker {
+		x, y := g.speakerAnchor(g.currentSpeaker)
+		wells = append(wells, gravityWell{X: x, Y: y})
+	}
+	if g.cfg.WellAtSplitCenter && g.state.CurrentState == "SPLIT" {
+		wells = append(wells, gravityWell{X: g.width / 2, Y: g.height / 2})
+	}
+	return wells
+}
+
+// applyWell accelerates b toward w if within range (inverse distance).
+func (g *Game) applyWell(b *BarrageWord, w gravityWell) {
+	dx := w.X - b.X
+	dy := w.Y - b.Y
+	d := math.Hypot(dx, dy)
+	if d > g.cfg.WellRadius || d < 1 {
+		return
+	}
+	force := g.cfg.WellStrength / math.Max(d, 20) * g.tickScale()
+	b.VX += dx / d * force
+	b.VY += dy / d * force
+}
+
+// applyAreaForces pushes words away from the pointers, then pulls the
+// flying ones into the wells, visiting only words near each via the grid.
+func (g *Game) applyAreaForces() {
+	pushers := g.cursorPushers()
+	wells := g.gravityWells()
+	if len(pushers) == 0 && len(wells) == 0 {
+		return
+	}
+
+	g.grid.rebuild(g.barrage, g.cfg.GridCell, g.width, g.height)
+	for _, p := range pushers {
+		g.grid.query(p.X, p.Y, g.cfg.MouseRadius, func(i int) {
+			g.applyPusher(&g.barrage[i], p)
+		})
+	}
+	for _, w := range wells {
+		g.grid.query(w.X, w.Y, g.cfg.WellRadius, func(i int) {
+			if b := &g.barrage[i]; !b.IsResting {
+				g.applyWell(b, w)
+			}
+		})
+	}
+}
+
+// applyAlignPull draws flying words toward the vertical center line in
+// proportion to the eased agreement level.
+func (g *Game) applyAlignPull(dt float64) {
+	k := g.cfg.AlignPull * g.alignment * dt
+	if k == 0 {
+		return
+	}
+	cx := g.width / 2
+	for i := range g.barrage {
+		b := &g.barrage[i]
+		if b.IsResting || b.IsSticky || b.IsShard || b.IsOrbiting {
+			continue
+		}
+		b.VX += (cx - b.X) * k
+	}
+}
+
+// updateChannelLevels keeps a slow-decaying energy per mic so the
+// speaker of an utterance is still known when its text arrives.
+func (g *Game) updateChannelLevels() {
+	k := math.Pow(0.97, g.tickScale())
+	select {
+	case raw := <-g.channelChan:
+		for i := range raw {
+			g.channelLevel[i] = math.Max(g.channelLevel[i]*k, raw[i])
+		}
+	default:
+		g.channelLevel[0] *= k
+		g.channelLevel[1] *= k
+	}
+
+	// Voiced energy since the last spawn, for the "energy" turn strategy
+	if g.micVolume > voiceFloor {
+		g.uttEnergy.mic += g.micVolume
+		g.uttEnergy.ch[0] += g.channelLevel[0]
+		g.uttEnergy.ch[1] += g.channelLevel[1]
+		g.uttEnergy.ticks++
+	}
+}
+
+// voiceFloor is the micVolume below which a tick counts as silence.
+const voiceFloor = 0.05
+
+// utteranceEnergy sums the levels heard while an utterance was spoken.
+type utteranceEnergy struct {
+	mic   float64
+	ch    [2]float64
+	ticks int
+}
+
+// speakerFromEnergy assigns the utterance by how it sounded over its
+// whole length rather than by the gap before it. With stereo input the
+// mic that carried more energy wins. With one mic each speaker has a
+// typical loudness (distance to the mic, voice): the utterance goes to
+// the speaker whose running level it is closest to, so a pause inside
+// one person's speech no longer flips the turn.
+func (g *Game) speakerFromEnergy() {
+	e := g.uttEnergy
+	g.uttEnergy = utteranceEnergy{}
+	if e.ticks == 0 {
+		return
+	}
+
+	if g.cfg.StereoInput {
+		if e.ch[0] > e.ch[1]*g.cfg.StereoMargin {
+			g.currentSpeaker = 0
+		} else if e.ch[1] > e.ch[0]*g.cfg.StereoMargin {
+			g.currentSpeaker = 1
+		}
+		return
+	}
+
+	level := e.mic / float64(e.ticks)
+	if level <= 0 {
+		return
+	}
+	known := &g.speakerLevel
+	switch {
+	case known[0] == 0:
+		g.currentSpeaker = 0
+	case known[1] == 0:
+		// The second voice is whoever first sounds clearly different
+		if math.Max(level, known[0]) > math.Min(level, known[0])*g.cfg.TurnEnergyMargin {
+			g.currentSpeaker = 1
+		} else {
+			g.currentSpeaker = 0
+		}
+	case math.Abs(math.Log(level/known[0])) <= math.Abs(math.Log(level/known[1])):
+		g.currentSpeaker = 0
+	default:
+		g.currentSpeaker = 1
+	}
+
+	// Follow slow drift (people lean in and out)
+	s := g.currentSpeaker
+	if known[s] == 0 {
+		known[s] = level
+	} else {
+		known[s] += (level - known[s]) * 0.2
+	}
+}
+
+// speakerFromChannels picks the side of the clearly louder mic. Near
+// ties (crosstalk, silence) keep the current speaker.
+func (g *Game) speakerFromChannels() {
+	l, r := g.channelLevel[0], g.channelLevel[1]
+	if l > r*g.cfg.StereoMargin {
+		g.currentSpeaker = 0
+	} else if r > l*g.cfg.StereoMargin {
+		g.currentSpeaker = 1
+	}
+}
+
+// cursorPusher is a mouse/touch point that shoves words away from it.
+type cursorPusher struct {
+	X, Y, Force float64
+}
+
+// cursorPushers returns the pointer positions pushing the barrage this tick.
+func (g *Game) cursorPushers() []cursorPusher {
+	if !g.cfg.MouseShove {
+		return nil
+	}
+
+	force := g.cfg.MouseForce
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		force *= g.cfg.MouseHeldBoost
+	}
+	x, y := ebiten.CursorPosition()
+	pushers := []cursorPusher{{X: float64(x), Y: float64(y), Force: force}}
+
+	for _, id := range ebiten.AppendTouchIDs(nil) {
+		tx, ty := ebiten.TouchPosition(id)
+		pushers = append(pushers, cursorPusher{X: float64(tx), Y: float64(ty), Force: force * g.cfg.MouseHeldBoost})
+	}
+	return pushers
+}
+
+// applyPusher pushes b away from p if within MouseRadius,
+// waking it up if it was resting on the floor.
+func (g *Game) applyPusher(b *BarrageWord, p cursorPusher) {
+	dx := b.X - p.X
+	dy := b.Y - p.Y
+	d := math.Hypot(dx, dy)
+	if d > g.cfg.MouseRadius || d < 1 {
+		return
+	}
+	falloff := (1 - d/g.cfg.MouseRadius) * g.tickScale()
+	b.VX += dx / d * p.Force * falloff
+	b.VY += dy / d * p.Force * falloff
+	b.IsResting = false
+}
+
+// triggerInvertV flips world gravity on a spoken "上下反転".
+// Momentary mode holds the flip for InvertDuration seconds;
+// latched mode toggles it until the next inversion phrase.
+func (g *Game) triggerInvertV() {
+	if !g.cfg.InvertWorld {
+		return
+	}
+	if g.cfg.InvertLatched {
+		g.invertLatched = !g.invertLatched
+		return
+	}
+	g.invertUntil = g.brain.Now().Add(time.Duration(g.cfg.InvertDuration * float64(time.Second)))
+}
+
+// updateGravityDir eases gravityDir toward -1 while inverted and back to 1.
+// Resting words are woken when the sign flips so the pile "falls" upward.
+func (g *Game) updateGravityDir() {
+	target := 1.0
+	if g.invertLatched || g.brain.Now().Before(g.invertUntil) {
+		target = -1.0
+	}
+
+	prev := g.gravityDir
+	k := 1 - math.Pow(0.9, g.tickScale())
+	g.gravityDir += (target - g.gravityDir) * k
+
+	if (prev >= 0) != (g.gravityDir >= 0) {
+		for i := range g.barrage {
+			if !g.barrage[i].IsSticky {
+				g.barrage[i].IsResting = false
+			}
+		}
+	}
+}
+
+// spawnShards bursts bw apart into one body per rune. Each shard
+// remembers its slot in the word and springs back to it after the
+// scatter, so the word can be read reassembling.
+func (g *Game) spawnShards(bw BarrageWord) {
+	runes := []rune(bw.Text)
+	advance := g.cfg.FontSizeBig * bw.Scale // roughly one full-width rune
+	left := bw.X - advance*float64(len(runes)-1)/2
+
+	for i, r := range runes {
+		s := bw
+		s.Text = string(r)
+		s.Image = nil
+		s.IsShard = true
+		s.HomeX = left + advance*float64(i)
+		s.HomeY = bw.Y
+		s.X = s.HomeX
+		s.VX = bw.VX + (g.rng.Float64()-0.5)*g.cfg.ShatterSpeed
+		s.VY = bw.VY + (g.rng.Float64()-0.5)*g.cfg.ShatterSpeed
+		s.VRotation = (g.rng.Float64() - 0.5) * 0.4
+		g.barrage = append(g.barrage, s)
+	}
+}
+
+// updateShard flies a shard ballistically during the scatter, then
+// springs it home. It reports false while the normal physics should run.
+func (g *Game) updateShard(b *BarrageWord, dt float64) bool {
+	age := float64(b.MaxLife-b.Life) * dt / 60.0
+	if age < g.cfg.ShatterScatter {
+		return false
+	}
+
+	b.VX += (b.HomeX - b.X) * 0.02 * dt
+	b.VY += (b.HomeY - b.Y) * 0.02 * dt
+	b.VX *= math.Pow(0.88, dt)
+	b.VY *= math.Pow(0.88, dt)
+	b.X += b.VX * dt
+	b.Y += b.VY * dt
+	b.Rotation *= math.Pow(0.9, dt)
+	return true
+}
+
+// startOrbit puts bw into a circular orbit around the screen center if
+// we're ALIGNED and there's room. The radius is its spawn distance.
+func (g *Game) startOrbit(bw *BarrageWord) {
+	if g.state.CurrentState != "ALIGNED" || g.cfg.OrbitMax <= 0 {
+		return
+	}
+	n := 0
+	for _, b := range g.barrage {
+		if b.IsOrbiting {
+			n++
+		}
+	}
+	if n >= g.cfg.OrbitMax {
+		return
+	}
+
+	dx := bw.X - g.width/2
+	dy := bw.Y - g.height/2
+	bw.IsOrbiting = true
+	bw.OrbitRadius = math.Max(math.Hypot(dx, dy), 150)
+	bw.OrbitAngle = math.Atan2(dy, dx)
+}
+
+// updateOrbit advances an orbiting word. When the calm breaks it lets go
+// along the tangent so the ring flies apart. Reports false once released.
+func (g *Game) updateOrbit(b *BarrageWord, dt float64) bool {
+	omega := g.cfg.OrbitSpeed / 60.0 // rad per tick
+	if g.state.CurrentState != "ALIGNED" {
+		b.IsOrbiting = false
+		b.VX = -math.Sin(b.OrbitAngle) * omega * b.OrbitRadius
+		b.VY = math.Cos(b.OrbitAngle) * omega * b.OrbitRadius
+		return false
+	}
+
+	b.OrbitAngle += omega * dt
+	b.X = g.width/2 + math.Cos(b.OrbitAngle)*b.OrbitRadius
+	b.Y = g.height/2 + math.Sin(b.OrbitAngle)*b.OrbitRadius
+	b.Rotation *= math.Pow(0.95, dt)
+	return true
+}
+
+// stepBarrage integrates every word and drops the expired ones. Large
+// barrages are sharded over PhysicsWorkers goroutines; the compaction
+// afterwards stays serial so the order matches the serial path.
+func (g *Game) stepBarrage(dt float64) {
+	workers := g.cfg.PhysicsWorkers
+	if workers > 1 && len(g.barrage) >= g.cfg.PhysicsParallelMin {
+		var wg sync.WaitGroup
+		chunk := (len(g.barrage) + workers - 1) / workers
+		for lo := 0; lo < len(g.barrage); lo += chunk {
+			part := g.barrage[lo:min(lo+chunk, len(g.barrage))]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range part {
+					g.stepWord(&part[i], dt)
+				}
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i := range g.barrage {
+			g.stepWord(&g.barrage[i], dt)
+		}
+	}
+
+	alive := g.barrage[:0]
+	for _, b := range g.barrage {
+		if b.Life > 0 {
+			alive = append(alive, b)
+		}
+	}
+	clear(g.barrage[len(alive):]) // Release the expired words' images
+	g.barrage = alive
+}
+
+// stepWord integrates one word for a tick. It only writes to b, so
+// words can be stepped concurrently.
+func (g *Game) stepWord(b *BarrageWord, dt float64) {
+	gravity := 0.25 * g.gravityDir
+	floorY := g.height - g.floorHeight()
+	ceilY := g.height - floorY // Floor when the world is upside down
+
+	if b.IsShard && g.updateShard(b, dt) {
+		// Reassembling
+	} else if b.IsOrbiting && g.updateOrbit(b, dt) {
+		// Circling the center
+	} else if b.IsResting {
+		// Ease into the settled tilt; the pile should look heavy and still
+		b.Rotation += (b.RestRotation - b.Rotation) * (1 - math.Pow(0.8, dt))
+	} else {
+		grav := gravity
+		if b.Gravity != 0 {
+			grav *= b.Gravity
+		} else if b.IsFiller {
+			grav *= 0.2
+		}
+
+		b.VY += grav * dt
+		b.X += b.VX * dt
+		b.Y += b.VY * dt
+		b.Rotation += b.VRotation * dt
+
+		b.VX *= math.Pow(0.98, dt)
+		b.VRotation *= math.Pow(0.98, dt)
+
+		if g.cfg.FloorMode == "none" {
+			// No floor: gone once well past the edge it falls toward
+			if b.Y > g.height+200 || b.Y < -200 {
+				b.Life = 0
+			}
+		} else if (g.gravityDir >= 0 && b.Y > floorY) || (g.gravityDir < 0 && b.Y < ceilY) {
+			if g.gravityDir >= 0 {
+				b.Y = floorY
+			} else {
+				b.Y = ceilY
+			}
+			b.VY *= -0.6
+			b.VX *= 0.8
+			if math.Abs(b.VY) < 1.0 {
+				b.VY = 0
+				settle(b)
+			}
+		}
+
+		if b.X < 50 || b.X > g.width-50 {
+			if b.IsSticky {
+				// Stick like a poster
+				b.X = math.Max(50, math.Min(g.width-50, b.X))
+				b.VX, b.VY = 0, 0
+				settle(b)
+			} else {
+				b.VX *= -0.8
+				b.X += b.VX
+			}
+		}
+	}
+
+	b.Life--
+}
+
+// settle puts b to rest. Its spin stops and it leans toward the nearest
+// upright (or upside-down) pose, keeping at most maxRestTilt of its lean.
+func settle(b *BarrageWord) {
+	b.IsResting = true
+	b.VRotation = 0
+	upright := math.Round(b.Rotation/math.Pi) * math.Pi
+	b.RestRotation = upright + math.Max(-maxRestTilt, math.Min(maxRestTilt, b.Rotation-upright))
+}
+
+// floorHeight is how far above the bottom edge words pile up.
+func (g *Game) floorHeight() float64 {
+	if g.cfg.FloorMode == "edge" {
+		return 0
+	}
+	return g.cfg.FloorHeight
+}
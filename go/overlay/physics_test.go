@@ -0,0 +1,61 @@
+package overlay
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// flyingGame builds a game with n freshly thrown words of mixed
+// lifetimes, so stepping them exercises flight, landing and expiry.
+func flyingGame(workers, n int) *Game {
+	cfg := DefaultConfig()
+	cfg.PhysicsWorkers = workers
+	cfg.PhysicsParallelMin = 1
+	g, _ := newTestGame(cfg, 7)
+	for i := range n {
+		g.spawnWordFromConfig(NewWordConfig(fmt.Sprintf("言葉%d", i)))
+	}
+	for i := range g.barrage {
+		g.barrage[i].Life = 50 + i%400
+	}
+	return g
+}
+
+// TestParallelPhysicsMatchesSerial steps the same barrage serially and
+// sharded over workers; every word must end up identical.
+func TestParallelPhysicsMatchesSerial(t *testing.T) {
+	const n = 1000
+	for _, workers := range []int{2, 3, 8} {
+		parallel := flyingGame(workers, n)
+		want := flyingGame(1, n)
+		for frame := range 300 {
+			want.stepBarrage(1)
+			parallel.stepBarrage(1)
+			if !reflect.DeepEqual(parallel.barrage, want.barrage) {
+				t.Fatalf("%d workers: barrage differs from serial at frame %d", workers, frame)
+			}
+		}
+		if len(want.barrage) == n || len(want.barrage) == 0 {
+			t.Fatalf("%d words left: expiry not exercised", len(want.barrage))
+		}
+	}
+}
+
+func BenchmarkStepBarrage(b *testing.B) {
+	for _, n := range []int{500, 2000, 10000} {
+		for _, workers := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("%d/workers=%d", n, workers), func(b *testing.B) {
+				g := flyingGame(workers, n)
+				for i := range g.barrage {
+					g.barrage[i].Life = 1 << 30
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for range b.N {
+					g.stepBarrage(1)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+// glitchBand is a horizontal "datamosh" slice of the frame, shifted
+// sideways (and optionally channel-swapped) for a tick or two.
+type glitchBand struct {
+	Y, H  int
+	Shift float64
+	Swap  bool
+	Life  int
+}
+
+// updateGlitchBands rolls new slices during SPLIT, more often at higher tension.
+func (g *Game) updateGlitchBands() {
+	alive := g.glitchBands[:0]
+	for _, b := range g.glitchBands {
+		b.Life--
+		if b.Life > 0 {
+			alive = append(alive, b)
+		}
+	}
+	g.glitchBands = alive
+
+	if !g.cfg.GlitchBlocks || g.state.CurrentState != "SPLIT" {
+		return
+	}
+	chance := g.cfg.GlitchRate * g.brain.Tension / 10.0 * g.tickScale()
+	for g.rng.Float64() < chance && len(g.glitchBands) < 8 {
+		g.glitchBands = append(g.glitchBands, glitchBand{
+			Y:     g.rng.Intn(int(g.height)),
+			H:     8 + g.rng.Intn(80),
+			Shift: (g.rng.Float64() - 0.5) * 240,
+			Swap:  g.rng.Float64() < 0.5,
+			Life:  1 + g.rng.Intn(2),
+		})
+		chance *= 0.5
+	}
+}
+
+// usesOffscreen reports whether the scene must be rendered offscreen
+// so a post-process can read it back.
+func (g *Game) usesOffscreen() bool {
+	return len(g.glitchBands) > 0 || g.mirrorX != 1 ||
+		(g.colorInvert > 0 && !g.cfg.ColorInvertCPU) || g.cfg.Opacity < 1
+}
+
+// mirrorGeoM flips x around the screen center by the eased mirror amount.
+func (g *Game) mirrorGeoM(m *ebiten.GeoM) {
+	m.Translate(-g.width/2, 0)
+	m.Scale(g.mirrorX, 1)
+	m.Translate(g.width/2, 0)
+}
+
+// sceneTarget returns the image the scene should be drawn into this frame.
+func (g *Game) sceneTarget(screen *ebiten.Image) *ebiten.Image {
+	if !g.usesOffscreen() {
+		return screen
+	}
+	if g.offscreen == nil {
+		g.offscreen = ebiten.NewImage(int(g.width), int(g.height))
+	}
+	return g.offscreen
+}
+
+// postProcess composites the offscreen scene onto the screen with the
+// active glitch bands applied. No-op when the scene went straight to screen.
+func (g *Game) postProcess(screen, scene *ebiten.Image) {
+	if scene == screen {
+		return
+	}
+	var cm colorm.ColorM
+	if g.colorInvert > 0 && !g.cfg.ColorInvertCPU {
+		cm = invertColorM(g.colorInvert)
+	}
+	if g.cfg.Opacity < 1 {
+		// See-through composite; the screen may still hold the last frame
+		cm.Scale(1, 1, 1, g.cfg.Opacity)
+		screen.Clear()
+	}
+
+	op := &colorm.DrawImageOptions{}
+	if g.mirrorX != 1 {
+		// Mid-flip the scene is narrower than the screen
+		bg := g.bgColor
+		if g.cfg.Opacity < 1 {
+			bg = scaleAlpha(bg, g.cfg.Opacity)
+		}
+		screen.Fill(bg)
+		g.mirrorGeoM(&op.GeoM)
+	}
+	colorm.DrawImage(screen, scene, cm, op)
+
+	var swap colorm.ColorM
+	swap.SetElement(0, 0, 0)
+	swap.SetElement(0, 2, 1)
+	swap.SetElement(2, 2, 0)
+	swap.SetElement(2, 0, 1)
+
+	for _, b := range g.glitchBands {
+		band := scene.SubImage(image.Rect(0, b.Y, int(g.width), b.Y+b.H)).(*ebiten.Image)
+		op := &colorm.DrawImageOptions{}
+		op.GeoM.Translate(b.Shift, float64(b.Y))
+		g.mirrorGeoM(&op.GeoM)
+		bandCM := cm
+		if b.Swap {
+			bandCM = swap
+			bandCM.Concat(cm)
+		}
+		colorm.DrawImage(screen, band, bandCM, op)
+	}
+}
+
+// scaleAlpha fades a premultiplied color by a.
+func scaleAlpha(c color.RGBA, a float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * a),
+		G: uint8(float64(c.G) * a),
+		B: uint8(float64(c.B) * a),
+		A: uint8(float64(c.A) * a),
+	}
+}
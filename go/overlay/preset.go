@@ -0,0 +1,110 @@
+package overlay
+
+import (
+	"path/filepath"
+	"reflect"
+)
+
+// presetFade blends from one config to the next. Numeric knobs are
+// interpolated; everything else switches at the start.
+type presetFade struct {
+	from, to Config
+	t        float64 // 0..1
+}
+
+// applyPreset switches to Presets[i], or back to the startup config when
+// i is -1. Other indices have no preset and are ignored. Called from
+// Update with the lock held.
+func (g *Game) applyPreset(i int) {
+	if i < -1 || i >= len(g.baseCfg.Presets) {
+		logDebug("Preset: no preset", i+1)
+		return
+	}
+	next := g.baseCfg.clone()
+	name := "startup"
+	if i >= 0 {
+		p := g.baseCfg.Presets[i]
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(g.baseCfg.AssetsDir, p)
+		}
+		cfg, err := loadConfigOver(g.baseCfg, p)
+		if err != nil {
+			logError("Preset Error:", err)
+			return
+		}
+		next, name = keepStartup(cfg, g.baseCfg), p
+	}
+	logInfo("Preset:", name)
+	g.preset = i
+
+	if next.PresetFade <= 0 {
+		g.setConfig(next)
+		g.vignette = nil
+		return
+	}
+	g.fade = &presetFade{from: g.cfg, to: next}
+	g.setConfig(lerpConfig(g.cfg, next, 0))
+}
+
+// updatePresetFade advances a running blend by dt seconds.
+func (g *Game) updatePresetFade(dt float64) {
+	if g.fade == nil {
+		return
+	}
+	g.fade.t += dt / max(g.fade.to.PresetFade, 0.01)
+	if g.fade.t >= 1 {
+		g.setConfig(g.fade.to)
+		g.fade = nil
+		g.vignette = nil // rebuilt once at the final strength
+		return
+	}
+	g.setConfig(lerpConfig(g.fade.from, g.fade.to, g.fade.t))
+}
+
+// setConfig swaps in cfg and pushes the parts held elsewhere.
+func (g *Game) setConfig(cfg Config) {
+	g.cfg = cfg
+	g.brain.Config = cfg.Brain
+	g.brain.Highlights = cfg.Highlights
+	g.watermarkImg = nil
+}
+
+// keepStartup restores the settings a running show can't change:
+// the window, audio setup, fonts and services.
+func keepStartup(cfg, base Config) Config {
+	cfg.Width, cfg.Height, cfg.TPS, cfg.FPSCap = base.Width, base.Height, base.TPS, base.FPSCap
+	cfg.AssetsDir, cfg.FontPaths = base.AssetsDir, base.FontPaths
+	cfg.FontSize, cfg.FontSizeBig, cfg.WatermarkSize = base.FontSize, base.FontSizeBig, base.WatermarkSize
+	cfg.Vocabulary, cfg.StereoInput = base.Vocabulary, base.StereoInput
+	cfg.AudioSource, cfg.Recognition = base.AudioSource, base.Recognition
+	cfg.WakeWord, cfg.WakeWindow = base.WakeWord, base.WakeWindow
+	cfg.MetricsAddr, cfg.LogLevel, cfg.BrainStatePath = base.MetricsAddr, base.LogLevel, base.BrainStatePath
+	cfg.NDIName, cfg.NDIWidth, cfg.NDIHeight, cfg.NDIFPS = base.NDIName, base.NDIWidth, base.NDIHeight, base.NDIFPS
+	cfg.DiscordToken, cfg.DiscordGuild, cfg.DiscordChannel = base.DiscordToken, base.DiscordGuild, base.DiscordChannel
+	cfg.VCamDevice, cfg.VCamWidth, cfg.VCamHeight, cfg.VCamFPS = base.VCamDevice, base.VCamWidth, base.VCamHeight, base.VCamFPS
+	cfg.Presets = base.Presets
+	return cfg
+}
+
+// lerpConfig returns b with every float64 field (nested structs and
+// arrays included) moved from a toward b by t.
+func lerpConfig(a, b Config, t float64) Config {
+	out := b
+	lerpFloats(reflect.ValueOf(&out).Elem(), reflect.ValueOf(a), t)
+	return out
+}
+
+func lerpFloats(dst, from reflect.Value, t float64) {
+	switch dst.Kind() {
+	case reflect.Float64:
+		dst.SetFloat(from.Float() + (dst.Float()-from.Float())*t)
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			lerpFloats(dst.Field(i), from.Field(i), t)
+		}
+	case reflect.Array:
+		for i := 0; i < dst.Len(); i++ {
+			lerpFloats(dst.Index(i), from.Index(i), t)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package overlay
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// recordBuffers is how many frames may wait on the PNG writer before
+// capture starts skipping.
+const recordBuffers = 8
+
+// recordedFrame is one frame on its way to disk.
+type recordedFrame struct {
+	n   int           // rendered frame number
+	t   time.Duration // since recording started
+	pix []byte
+}
+
+// recorder writes every Nth rendered frame as a numbered PNG plus a
+// manifest.csv of frame number, file and time, for offline compositing.
+type recorder struct {
+	dir     string
+	every   int
+	max     int // frames to write, 0 = unlimited
+	start   time.Time
+	frame   int // rendered frames seen
+	written int // frames handed to the writer
+	skipped int
+	w, h    int
+	free    chan []byte
+	ready   chan recordedFrame
+	done    chan struct{}
+}
+
+// startRecorder creates dir and starts the writer.
+func startRecorder(dir string, cfg Config) (*recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	manifest, err := os.Create(filepath.Join(dir, "manifest.csv"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &recorder{
+		dir:   dir,
+		every: max(cfg.RecordEvery, 1),
+		max:   cfg.RecordMaxFrames,
+		start: time.Now(),
+		w:     cfg.Width,
+		h:     cfg.Height,
+		free:  make(chan []byte, recordBuffers),
+		ready: make(chan recordedFrame, recordBuffers),
+		done:  make(chan struct{}),
+	}
+	for range recordBuffers {
+		r.free <- make([]byte, 4*r.w*r.h)
+	}
+	go r.run(manifest)
+	logInfo("Recording frames to", dir)
+	return r, nil
+}
+
+// capture reads screen back if this frame is due. A nil *recorder does
+// nothing.
+func (r *recorder) capture(screen *ebiten.Image) {
+	if r == nil {
+		return
+	}
+	r.frame++
+	if (r.frame-1)%r.every != 0 || (r.max > 0 && r.written >= r.max) {
+		return
+	}
+
+	var pix []byte
+	select {
+	case pix = <-r.free:
+	default:
+		r.skipped++
+		if r.skipped == 1 || r.skipped%100 == 0 {
+			logWarn("Record: disk can't keep up, skipped", r.skipped, "frames")
+		}
+		return
+	}
+	screen.ReadPixels(pix)
+	r.ready <- recordedFrame{n: r.frame, t: time.Since(r.start), pix: pix}
+
+	r.written++
+	if r.written == r.max {
+		logInfo("Record: reached", r.max, "frames, stopping")
+	}
+}
+
+func (r *recorder) run(manifest *os.File) {
+	defer close(r.done)
+	defer manifest.Close()
+
+	out := bufio.NewWriter(manifest)
+	defer out.Flush()
+	fmt.Fprintln(out, "frame,file,seconds")
+
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	for f := range r.ready {
+		name := fmt.Sprintf("frame_%06d.png", f.n)
+		unpremultiply(f.pix)
+		img := &image.NRGBA{Pix: f.pix, Stride: 4 * r.w, Rect: image.Rect(0, 0, r.w, r.h)}
+		if err := writePNG(filepath.Join(r.dir, name), img, &enc); err != nil {
+			logError("Record Error:", err)
+		} else {
+			fmt.Fprintf(out, "%d,%s,%.4f\n", f.n, name, f.t.Seconds())
+		}
+		r.free <- f.pix
+	}
+}
+
+func writePNG(path string, img image.Image, enc *png.Encoder) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// close waits for queued frames to reach disk.
+func (r *recorder) close() {
+	if r == nil {
+		return
+	}
+	close(r.ready)
+	<-r.done
+	logInfo("Record:", r.written, "frames written,", r.skipped, "skipped")
+}
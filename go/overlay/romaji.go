@@ -0,0 +1,104 @@
+package overlay
+
+import "strings"
+
+// Hiragana -> Hepburn romaji. Katakana is folded onto hiragana first.
+var kanaRomaji = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "wi", "ゑ": "we", "を": "wo", "ん": "n",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ゔ": "vu",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa",
+
+	"きゃ": "kya", "きゅ": "kyu", "きょ": "kyo",
+	"しゃ": "sha", "しゅ": "shu", "しょ": "sho", "しぇ": "she",
+	"ちゃ": "cha", "ちゅ": "chu", "ちょ": "cho", "ちぇ": "che",
+	"にゃ": "nya", "にゅ": "nyu", "にょ": "nyo",
+	"ひゃ": "hya", "ひゅ": "hyu", "ひょ": "hyo",
+	"みゃ": "mya", "みゅ": "myu", "みょ": "myo",
+	"りゃ": "rya", "りゅ": "ryu", "りょ": "ryo",
+	"ぎゃ": "gya", "ぎゅ": "gyu", "ぎょ": "gyo",
+	"じゃ": "ja", "じゅ": "ju", "じょ": "jo", "じぇ": "je",
+	"びゃ": "bya", "びゅ": "byu", "びょ": "byo",
+	"ぴゃ": "pya", "ぴゅ": "pyu", "ぴょ": "pyo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "うぃ": "wi", "うぇ": "we", "うぉ": "wo",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+}
+
+// toRomaji transliterates the kana in s. Kanji and other scripts are
+// dropped, ASCII passes through, and runs are joined by single spaces.
+func toRomaji(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if r >= 'ァ' && r <= 'ヶ' {
+			runes[i] = r - 0x60 // Katakana -> Hiragana
+		}
+	}
+
+	var out strings.Builder
+	geminate := false // っ doubles the next consonant
+	gap := false      // a dropped kanji run becomes one space
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if i+1 < len(runes) {
+			if ro, ok := kanaRomaji[string(runes[i:i+2])]; ok {
+				writeRomaji(&out, ro, &geminate, &gap)
+				i++
+				continue
+			}
+		}
+		if ro, ok := kanaRomaji[string(r)]; ok {
+			writeRomaji(&out, ro, &geminate, &gap)
+			continue
+		}
+
+		switch {
+		case r == 'っ':
+			geminate = true
+		case r == 'ー':
+			if str := out.String(); len(str) > 0 {
+				out.WriteByte(str[len(str)-1]) // Long vowel
+			}
+		case r < 0x80 && r != ' ':
+			if gap && out.Len() > 0 {
+				out.WriteByte(' ')
+			}
+			gap = false
+			out.WriteRune(r)
+		default:
+			gap = true
+		}
+	}
+	return out.String()
+}
+
+func writeRomaji(out *strings.Builder, ro string, geminate, gap *bool) {
+	if *gap && out.Len() > 0 {
+		out.WriteByte(' ')
+	}
+	*gap = false
+	if *geminate {
+		if ro[0] == 'c' {
+			out.WriteByte('t') // っち -> tchi
+		} else {
+			out.WriteByte(ro[0])
+		}
+		*geminate = false
+	}
+	out.WriteString(ro)
+}
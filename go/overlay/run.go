@@ -0,0 +1,201 @@
+// Package overlay is the spatial renderer: it listens to a conversation,
+// runs it through the Brain and throws the words around an Ebiten window.
+//
+// NewGame builds the bare engine (no audio, fonts or window), which is
+// enough to drive it from code with SpawnWord / SetState. New adds
+// everything the live show needs, and Run opens the window.
+package overlay
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Options are the per-run choices that don't belong in a config file.
+type Options struct {
+	ConfigPath string // watched for live reload when set
+	Seed       int64  // 0 = time based
+	Fullscreen bool
+	RecordDir  string // write frames here ("" = off)
+	Discord    bool   // also listen to the configured Discord channel
+}
+
+// SetLogLevel sets the package log level.
+func SetLogLevel(l LogLevel) {
+	logLevel = l
+}
+
+// New builds a Game ready for Run: fonts loaded, the microphone listening
+// and the configured outputs started. Failures only log: a missing font
+// falls back to the bitmap font, a failed output stays off.
+func New(cfg Config, opts Options) *Game {
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	game := NewGame(cfg, opts.Seed, time.Now)
+	game.fullscreen = opts.Fullscreen
+
+	// Load Fonts
+	tt, err := loadFont(cfg)
+	if err != nil {
+		logError("Font Error:", err)
+		game.setFallbackFaces(err)
+	} else {
+		game.setFaces(tt)
+	}
+
+	// Audio Init
+	if game.speech = NewSpeechEngine(cfg.Vocabulary, cfg.Recognition); game.speech != nil {
+		game.speech.WakeWord = cfg.WakeWord
+		game.speech.WakeWindow = time.Duration(cfg.WakeWindow * float64(time.Second))
+		game.speech.Stereo = cfg.StereoInput
+		game.speech.Loopback = cfg.AudioSource == "loopback"
+		game.speech.Start()
+		game.textChan = game.speech.TextChan
+		game.audioChan = game.speech.VolChan
+		game.spectrumChan = game.speech.SpectrumChan
+		game.channelChan = game.speech.ChannelChan
+	}
+
+	if opts.Discord {
+		if stop, err := startDiscord(cfg, game.speech); err != nil {
+			logError("Discord Error:", err)
+		} else {
+			game.closers = append(game.closers, stop)
+		}
+	}
+
+	if cfg.MetricsAddr != "" {
+		game.metrics = startMetrics(cfg.MetricsAddr, game.speech)
+	}
+
+	if cfg.NDIName != "" {
+		if game.ndi, err = startNDI(cfg); err != nil {
+			logError("NDI Error:", err)
+		}
+		game.closers = append(game.closers, game.ndi.close)
+	}
+
+	if cfg.VCamDevice != "" {
+		if game.vcam, err = startVCam(cfg); err != nil {
+			logError("Virtual Camera Error:", err)
+		}
+		game.closers = append(game.closers, game.vcam.close)
+	}
+
+	if opts.RecordDir != "" {
+		if game.recorder, err = startRecorder(opts.RecordDir, cfg); err != nil {
+			logError("Record Error:", err)
+		}
+		game.closers = append(game.closers, game.recorder.close)
+	}
+
+	if opts.ConfigPath != "" && cfg.WatchConfig {
+		game.watchConfig(opts.ConfigPath)
+	}
+
+	if cfg.BrainStatePath != "" {
+		if err := game.brain.Load(cfg.BrainStatePath); err != nil && !os.IsNotExist(err) {
+			logError("Brain Load Error:", err)
+		}
+	}
+	return game
+}
+
+// Run opens the window and blocks until it closes, then saves the
+// Brain's mood and shuts the outputs down.
+func (g *Game) Run() error {
+	cfg := g.baseCfg
+	ebiten.SetWindowSize(cfg.Width, cfg.Height)
+	ebiten.SetWindowTitle("脳内劇場")
+	ebiten.SetWindowFloating(true)
+	ebiten.SetWindowDecorated(false)
+	ebiten.SetScreenTransparent(true)
+	ebiten.SetTPS(cfg.TPS)
+	if cfg.FPSCap > 0 {
+		// Skipped Draw calls must leave the last frame intact
+		ebiten.SetScreenClearedEveryFrame(false)
+	}
+	setFullscreen(g.fullscreen)
+
+	err := ebiten.RunGame(g)
+
+	if cfg.BrainStatePath != "" {
+		if err := g.brain.Save(cfg.BrainStatePath); err != nil {
+			logError("Brain Save Error:", err)
+		}
+	}
+	for i := len(g.closers) - 1; i >= 0; i-- {
+		g.closers[i]()
+	}
+	return err
+}
+
+// SpawnWord runs text through the Brain and spawns it as if it had been
+// heard. Safe to call from any goroutine.
+func (g *Game) SpawnWord(text string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.spawnText(text, false)
+}
+
+// SetState forces the Brain into UNKNOWN, ALIGNED or SPLIT by moving its
+// tension into that state's band; it then decays as usual. Safe to call
+// from any goroutine.
+func (g *Game) SetState(state string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c := g.brain.Config
+	switch state {
+	case "UNKNOWN":
+		g.brain.Tension = 0
+	case "ALIGNED":
+		g.brain.Tension = (c.AlignedThreshold + c.SplitThreshold) / 2
+	case "SPLIT":
+		g.brain.Tension = c.SplitThreshold + 1
+	default:
+		return fmt.Errorf("unknown state %q", state)
+	}
+	g.brain.LastUpdate = g.brain.Now()
+	g.state.CurrentState = g.brain.GetState()
+	return nil
+}
+
+// SpawnEvent describes a word as it enters the barrage.
+type SpawnEvent struct {
+	Text         string
+	X, Y, VX, VY float64
+	Scale        float64
+	Color        color.RGBA
+	Style        string
+}
+
+// SpawnEvents returns a channel that receives every spawned word from
+// now on, for tests and external renderers. Events are dropped, never
+// waited for, when the buffer is full. Until it is called nothing is
+// built or sent.
+func (g *Game) SpawnEvents(buffer int) <-chan SpawnEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.spawnEvents == nil {
+		g.spawnEvents = make(chan SpawnEvent, buffer)
+	}
+	return g.spawnEvents
+}
+
+// emitSpawn reports b to the SpawnEvents subscriber, if there is one.
+func (g *Game) emitSpawn(b BarrageWord, style string) {
+	if g.spawnEvents == nil {
+		return
+	}
+	c, _ := b.Color.(color.RGBA)
+	select {
+	case g.spawnEvents <- SpawnEvent{b.Text, b.X, b.Y, b.VX, b.VY, b.Scale, c, style}:
+	default:
+	}
+}
@@ -0,0 +1,385 @@
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	vosk "github.com/alphacep/vosk-api/go"
+	"github.com/gen2brain/malgo"
+)
+
+const sampleRate = 44100
+
+// SpeechEngine handles STT
+type SpeechEngine struct {
+	model      *vosk.VoskModel
+	recognizer *vosk.VoskRecognizer
+	grammar    string // JSON phrase list, "" = free recognition
+	device     *malgo.Device
+
+	TextChan     chan Utterance
+	VolChan      chan float64
+	SpectrumChan chan Bands
+	ChannelChan  chan [2]float64 // per-channel RMS (Stereo only)
+
+	// Most recent mono samples for the oscilloscope
+	waveMu  sync.Mutex
+	wave    [waveformSize]float32
+	waveEnd int // next write position in wave
+
+	// Capture two channels (L/R mics); Vosk still gets a mono downmix
+	Stereo bool
+	mono   []int16
+
+	// Capture what the system plays instead of a mic (Windows only)
+	Loopback bool
+
+	// Wake word: when set, results are ignored until it is heard, then
+	// pass for WakeWindow before the gate re-arms
+	WakeWord   string
+	WakeWindow time.Duration
+	awakeUntil time.Time
+
+	// Messages dropped because the game wasn't draining the channels.
+	// Lost levels only glitch the visuals; lost text is lost speech.
+	DroppedVol  atomic.Uint64
+	DroppedText atomic.Uint64
+
+	// Muted drops all input at the source: no levels, no recognition
+	Muted atomic.Bool
+
+	// Band filter state (only touched from the audio callback)
+	lowBass, lowMid float64
+}
+
+// Utterance is one final recognition result.
+type Utterance struct {
+	Text    string
+	Conf    float64 // mean word confidence, 0..1 (1 when Vosk gives none)
+	Speaker int     // known speaker (Discord user slot), -1 = decide locally
+}
+
+// voskResult is the part of Vosk's final result JSON we read.
+type voskResult struct {
+	Text   string `json:"text"`
+	Result []struct {
+		Conf float64 `json:"conf"`
+	} `json:"result"`
+}
+
+// Bands is the RMS energy of one audio buffer split into
+// bass / mid / treble with a pair of one-pole low-pass filters.
+type Bands [3]float64
+
+const (
+	BandBass = iota
+	BandMid
+	BandTreble
+)
+
+const (
+	bassCutoff = 250.0  // Hz
+	midCutoff  = 2000.0 // Hz
+)
+
+// NewSpeechEngine loads the model. A non-empty vocabulary restricts
+// recognition to those phrases (anything else comes back as [unk]),
+// which is far more reliable for a scripted show. Grammars need a model
+// with a runtime graph; the big model ignores them. Without recognize
+// no model is loaded and the engine only reports levels.
+func NewSpeechEngine(vocabulary []string, recognize bool) *SpeechEngine {
+	if !recognize {
+		logInfo("Speech: recognition off, levels only")
+		return newSpeechEngine(nil)
+	}
+
+	// Suppress Vosk logs
+	vosk.SetLogLevel(-1)
+
+	// LOAD BIG MODEL (High Fidelity)
+	model, err := vosk.NewModel("vosk/vosk-model-ja-0.22")
+	if err != nil {
+		logError("Vosk Model Error:", err)
+		return nil
+	}
+
+	se := newSpeechEngine(model)
+	if len(vocabulary) > 0 {
+		grammar, _ := json.Marshal(append(slices.Clone(vocabulary), "[unk]"))
+		se.grammar = string(grammar)
+		logInfo("Vosk Grammar:", len(vocabulary), "phrases")
+	}
+	if se.recognizer, err = se.newRecognizer(sampleRate); err != nil {
+		logError("Vosk Recognizer Error:", err)
+		return nil
+	}
+	return se
+}
+
+func newSpeechEngine(model *vosk.VoskModel) *SpeechEngine {
+	return &SpeechEngine{
+		model:        model,
+		TextChan:     make(chan Utterance, 10),
+		VolChan:      make(chan float64, 10),
+		SpectrumChan: make(chan Bands, 10),
+		ChannelChan:  make(chan [2]float64, 10),
+	}
+}
+
+// newRecognizer makes a recognizer on the shared model for audio at rate.
+func (se *SpeechEngine) newRecognizer(rate float64) (*vosk.VoskRecognizer, error) {
+	if se.model == nil {
+		return nil, fmt.Errorf("recognition is off")
+	}
+	var rec *vosk.VoskRecognizer
+	var err error
+	if se.grammar != "" {
+		rec, err = vosk.NewRecognizerGrm(se.model, rate, se.grammar)
+	} else {
+		rec, err = vosk.NewRecognizer(se.model, rate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Per-word results carry the confidence
+	rec.SetWords(1)
+	return rec, nil
+}
+
+func (se *SpeechEngine) Start() {
+	ctx, err := malgo.InitContext(nil, malgo.ContextConfig{}, nil)
+	if err != nil {
+		logError("Audio Context Error:", err)
+		return
+	}
+
+	// One-pole low-pass coefficients for the band split
+	bassAlpha := 1 - math.Exp(-2*math.Pi*bassCutoff/sampleRate)
+	midAlpha := 1 - math.Exp(-2*math.Pi*midCutoff/sampleRate)
+
+	deviceConfig := malgo.DefaultDeviceConfig(malgo.Duplex)
+	if se.Loopback {
+		if runtime.GOOS == "windows" {
+			deviceConfig = malgo.DefaultDeviceConfig(malgo.Loopback)
+			logInfo("Audio: capturing system audio (loopback)")
+		} else {
+			logError("Audio Error: loopback capture needs WASAPI (Windows); using the microphone")
+		}
+	}
+	deviceConfig.Capture.Format = malgo.FormatS16
+	deviceConfig.Capture.Channels = 1
+	if se.Stereo {
+		deviceConfig.Capture.Channels = 2
+	}
+	deviceConfig.SampleRate = sampleRate
+	deviceConfig.Alsa.NoMMap = 1
+
+	// Buffer for Vosk (Keep it reasonably sized)
+	// malgo callbacks happen on a separate thread
+	deviceCallbacks := malgo.DeviceCallbacks{
+		Data: func(pOutputSample, pInputSample []byte, framecount uint32) {
+			if se.Muted.Load() {
+				return
+			}
+
+			// 1. Calculate Volume (RMS)
+			sum := 0.0
+			// pInputSample is S16LE (2 bytes)
+			// Process every sample
+			sh := (*(*[]int16)(unsafe.Pointer(&pInputSample)))[:framecount]
+			if se.Stereo && framecount > 0 {
+				sh = se.downmix(pInputSample, framecount)
+				pInputSample = unsafe.Slice((*byte)(unsafe.Pointer(&sh[0])), len(sh)*2)
+			}
+
+			var bandSum Bands
+			se.pushWaveform(sh)
+
+			for _, v := range sh {
+				val := float64(v) / 32768.0
+				sum += val * val
+
+				se.lowBass += bassAlpha * (val - se.lowBass)
+				se.lowMid += midAlpha * (val - se.lowMid)
+				bass := se.lowBass
+				mid := se.lowMid - se.lowBass
+				treble := val - se.lowMid
+				bandSum[BandBass] += bass * bass
+				bandSum[BandMid] += mid * mid
+				bandSum[BandTreble] += treble * treble
+			}
+			rms := math.Sqrt(sum / float64(framecount))
+
+			var bands Bands
+			for i := range bandSum {
+				bands[i] = math.Sqrt(bandSum[i] / float64(framecount))
+			}
+
+			// Non-blocking send
+			select {
+			case se.VolChan <- rms:
+			default:
+				se.DroppedVol.Add(1)
+			}
+			select {
+			case se.SpectrumChan <- bands:
+			default:
+			}
+
+			// 2. Feed to Vosk
+			// Vosk expects []byte directly
+			if se.recognizer == nil {
+				return
+			}
+			if se.recognizer.AcceptWaveform(pInputSample) != 0 {
+				u := parseResult(se.recognizer.Result(), -1)
+				if u.Text = se.gate(u.Text); u.Text != "" {
+					se.emit(u)
+				}
+			} else {
+				// Partial results? (Optional, maybe too noisy for this visual style)
+				// var partial map[string]string
+				// json.Unmarshal([]byte(se.recognizer.PartialResult()), &partial)
+			}
+		},
+	}
+
+	device, err := malgo.InitDevice(ctx.Context, deviceConfig, deviceCallbacks)
+	if err != nil {
+		logError("Audio Device Error:", err)
+		return
+	}
+	se.device = device
+
+	if err := device.Start(); err != nil {
+		logError("Audio Start Error:", err)
+	}
+}
+
+// parseResult reads a Vosk final result, dropping [unk] tokens. A result
+// with nothing but spaces and punctuation left comes back with no text.
+func parseResult(js string, speaker int) Utterance {
+	var res voskResult
+	json.Unmarshal([]byte(js), &res)
+	text := strings.TrimSpace(strings.ReplaceAll(res.Text, "[unk]", ""))
+	if blankText(text) {
+		text = ""
+	}
+	return Utterance{
+		Text:    text,
+		Conf:    res.conf(),
+		Speaker: speaker,
+	}
+}
+
+// emit hands an utterance to the game without ever blocking the audio side.
+func (se *SpeechEngine) emit(u Utterance) {
+	select {
+	case se.TextChan <- u:
+	default:
+		se.DroppedText.Add(1)
+		logWarn("Speech: game fell behind, dropped", u.Text)
+	}
+}
+
+// conf averages the word confidences, 1 if there are none.
+func (r voskResult) conf() float64 {
+	if len(r.Result) == 0 {
+		return 1
+	}
+	sum := 0.0
+	for _, w := range r.Result {
+		sum += w.Conf
+	}
+	return sum / float64(len(r.Result))
+}
+
+// gate applies the wake word to one result, returning what should reach
+// TextChan ("" to drop it). Only called from the audio callback.
+func (se *SpeechEngine) gate(txt string) string {
+	if se.WakeWord == "" {
+		return txt
+	}
+
+	now := time.Now()
+	if !se.awakeUntil.IsZero() && now.After(se.awakeUntil) {
+		se.awakeUntil = time.Time{}
+		logInfo("Wake: re-armed")
+	}
+
+	if i := strings.Index(txt, se.WakeWord); i >= 0 {
+		if se.awakeUntil.IsZero() {
+			logInfo("Wake: listening for", se.WakeWindow)
+		}
+		se.awakeUntil = now.Add(se.WakeWindow)
+		// Drop the trigger itself, keep anything said after it
+		return strings.TrimSpace(txt[i+len(se.WakeWord):])
+	}
+
+	if se.awakeUntil.IsZero() {
+		return ""
+	}
+	return txt
+}
+
+// pushWaveform appends samples to the oscilloscope ring buffer.
+func (se *SpeechEngine) pushWaveform(sh []int16) {
+	se.waveMu.Lock()
+	defer se.waveMu.Unlock()
+	for _, v := range sh {
+		se.wave[se.waveEnd] = float32(v) / 32768.0
+		se.waveEnd = (se.waveEnd + 1) % waveformSize
+	}
+}
+
+// Waveform copies the recent samples, oldest first, into dst.
+func (se *SpeechEngine) Waveform(dst []float32) []float32 {
+	se.waveMu.Lock()
+	defer se.waveMu.Unlock()
+	dst = append(dst[:0], se.wave[se.waveEnd:]...)
+	return append(dst, se.wave[:se.waveEnd]...)
+}
+
+// downmix averages interleaved L/R frames into se.mono and reports the
+// per-channel RMS on ChannelChan.
+func (se *SpeechEngine) downmix(pInputSample []byte, framecount uint32) []int16 {
+	in := (*(*[]int16)(unsafe.Pointer(&pInputSample)))[:framecount*2]
+	if cap(se.mono) < int(framecount) {
+		se.mono = make([]int16, framecount)
+	}
+	se.mono = se.mono[:framecount]
+
+	var sum [2]float64
+	for i := range se.mono {
+		l, r := float64(in[2*i])/32768.0, float64(in[2*i+1])/32768.0
+		sum[0] += l * l
+		sum[1] += r * r
+		se.mono[i] = int16((int32(in[2*i]) + int32(in[2*i+1])) / 2)
+	}
+
+	levels := [2]float64{
+		math.Sqrt(sum[0] / float64(framecount)),
+		math.Sqrt(sum[1] / float64(framecount)),
+	}
+	select {
+	case se.ChannelChan <- levels:
+	default:
+	}
+	return se.mono
+}
+
+func (se *SpeechEngine) Close() {
+	if se.device != nil {
+		se.device.Uninit()
+	}
+	// Vosk cleaning is manual in Go bindings?
+	// Binding usually uses runtime.SetFinalizer, but good to check
+}
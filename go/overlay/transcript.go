@@ -0,0 +1,71 @@
+package overlay
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+const transcriptMargin = 40
+
+// transcriptPanel keeps the last few recognized lines for the corner
+// readout. scroll is the fraction of a line still to slide up after the
+// newest line arrived.
+type transcriptPanel struct {
+	visible bool
+	lines   []string
+	scroll  float64
+}
+
+// add appends a heard line tagged with its speaker, keeping at most keep.
+func (t *transcriptPanel) add(s string, speaker, keep int) {
+	if keep <= 0 || strings.TrimSpace(s) == "" {
+		return
+	}
+	tag := "A"
+	if speaker == 1 {
+		tag = "B"
+	}
+	t.lines = append(t.lines, tag+": "+s)
+	if len(t.lines) > keep {
+		t.lines = t.lines[len(t.lines)-keep:]
+	}
+	t.scroll = 1
+}
+
+// drawTranscript lists the recent lines left-aligned in the configured
+// corner, oldest faintest, sliding up as new ones arrive.
+func (g *Game) drawTranscript(screen *ebiten.Image) {
+	g.mu.Lock() // boundString fills its cache
+	defer g.mu.Unlock()
+
+	t := &g.transcript
+	if !t.visible || len(t.lines) == 0 || g.jpFace == nil {
+		return
+	}
+	lineH := float64(g.jpFace.Metrics().Height.Ceil())
+
+	width := 0
+	for _, l := range t.lines {
+		width = max(width, g.boundString(g.jpFace, l).Dx())
+	}
+	x := float64(transcriptMargin)
+	if strings.HasSuffix(g.cfg.TranscriptPos, "right") {
+		x = g.width - transcriptMargin - float64(width)
+	}
+	// Baseline of the newest line
+	y := g.height - 2*transcriptMargin
+	if strings.HasPrefix(g.cfg.TranscriptPos, "top") {
+		y = transcriptMargin + lineH*float64(len(t.lines))
+	}
+	y += t.scroll * lineH
+
+	n := len(t.lines)
+	for i, l := range t.lines {
+		a := uint8(240 * float64(i+1) / float64(n) * g.cfg.Opacity)
+		ly := y - lineH*float64(n-1-i)
+		text.Draw(screen, l, g.jpFace, int(x), int(ly), color.RGBA{a, a, a, a})
+	}
+}
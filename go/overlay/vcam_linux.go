@@ -0,0 +1,101 @@
+package overlay
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// V4L2 output setup (linux/videodev2.h)
+const (
+	vidiocSFmt         = 0xc0d05605 // _IOWR('V', 5, struct v4l2_format)
+	v4l2BufTypeOutput  = 2
+	v4l2FieldNone      = 1
+	v4l2PixFmtYUYV     = 'Y' | 'U'<<8 | 'Y'<<16 | 'V'<<24
+	v4l2ColorspaceSRGB = 8
+)
+
+type v4l2PixFormat struct {
+	Width, Height, PixelFormat, Field uint32
+	BytesPerLine, SizeImage           uint32
+	Colorspace, Priv, Flags           uint32
+	YcbcrEnc, Quantization, XferFunc  uint32
+}
+
+// v4l2Format is struct v4l2_format with the pix member of its 200 byte
+// union (8-aligned on 64-bit, hence the pad).
+type v4l2Format struct {
+	Type uint32
+	_    uint32
+	Pix  v4l2PixFormat
+	_    [200 - unsafe.Sizeof(v4l2PixFormat{})]byte
+}
+
+// v4l2Camera writes YUYV frames to a v4l2loopback device, which video
+// call apps list as a camera (OBS's virtual camera is the same device).
+type v4l2Camera struct {
+	f    *os.File
+	yuyv []byte
+}
+
+// startVCam opens cfg.VCamDevice and sets its format.
+func startVCam(cfg Config) (*videoOutput, error) {
+	w, h, fps := cfg.VCamWidth&^1, cfg.VCamHeight, cfg.VCamFPS // YUYV pairs pixels
+	if w <= 0 || h <= 0 || fps <= 0 {
+		return nil, fmt.Errorf("need a positive vcam size and fps, got %dx%d@%d", w, h, fps)
+	}
+	f, err := os.OpenFile(cfg.VCamDevice, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	format := v4l2Format{
+		Type: v4l2BufTypeOutput,
+		Pix: v4l2PixFormat{
+			Width:        uint32(w),
+			Height:       uint32(h),
+			PixelFormat:  v4l2PixFmtYUYV,
+			Field:        v4l2FieldNone,
+			BytesPerLine: uint32(2 * w),
+			SizeImage:    uint32(2 * w * h),
+			Colorspace:   v4l2ColorspaceSRGB,
+		},
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), vidiocSFmt, uintptr(unsafe.Pointer(&format))); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("%s: set format: %w (is it a v4l2loopback device?)", cfg.VCamDevice, errno)
+	}
+
+	logInfo("Virtual Camera:", cfg.VCamDevice)
+	return startVideoOutput(&v4l2Camera{f: f, yuyv: make([]byte, 2*w*h)}, w, h, fps), nil
+}
+
+// send converts the premultiplied frame (i.e. composited over black)
+// to BT.601 YUYV and writes it.
+func (c *v4l2Camera) send(pix []byte, w, h int) {
+	for i, o := 0, 0; i < len(pix); i, o = i+8, o+4 {
+		y0, u0, v0 := yuv(pix[i], pix[i+1], pix[i+2])
+		y1, u1, v1 := yuv(pix[i+4], pix[i+5], pix[i+6])
+		c.yuyv[o] = y0
+		c.yuyv[o+1] = uint8((int(u0) + int(u1)) / 2)
+		c.yuyv[o+2] = y1
+		c.yuyv[o+3] = uint8((int(v0) + int(v1)) / 2)
+	}
+	if _, err := c.f.Write(c.yuyv); err != nil {
+		logError("Virtual Camera Error:", err)
+	}
+}
+
+func (c *v4l2Camera) close() {
+	c.f.Close()
+}
+
+// yuv is the limited-range BT.601 conversion cameras expect.
+func yuv(r, g, b uint8) (uint8, uint8, uint8) {
+	R, G, B := int(r), int(g), int(b)
+	y := (66*R+129*G+25*B+128)>>8 + 16
+	u := (-38*R-74*G+112*B+128)>>8 + 128
+	v := (112*R-94*G-18*B+128)>>8 + 128
+	return uint8(y), uint8(u), uint8(v)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package overlay
+
+import "errors"
+
+func startVCam(cfg Config) (*videoOutput, error) {
+	return nil, errors.New("the virtual camera needs Linux and v4l2loopback; elsewhere use the NDI output with a virtual camera bridge")
+}
@@ -0,0 +1,94 @@
+package overlay
+
+import (
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces the burst of events a single save produces.
+const reloadDebounce = 250 * time.Millisecond
+
+// watchConfig re-applies the config file at path whenever it is saved.
+// The watcher only loads and validates; Update applies the result.
+func (g *Game) watchConfig(path string) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		logError("Config Watch Error:", err)
+		return
+	}
+	// Watch the directory: many editors save by replacing the file
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		logError("Config Watch Error:", err)
+		w.Close()
+		return
+	}
+	path = filepath.Clean(path)
+	g.reloads = make(chan Config, 1)
+
+	go func() {
+		defer w.Close()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) == path && (ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create)) {
+					debounce = time.After(reloadDebounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				logError("Config Watch Error:", err)
+			case <-debounce:
+				debounce = nil
+				g.reloadConfig(path)
+			}
+		}
+	}()
+}
+
+// reloadConfig loads path on the watcher goroutine and queues it for
+// Update. A newer save replaces one that hasn't been applied yet.
+func (g *Game) reloadConfig(path string) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		logError("Config Reload Error:", err)
+		return
+	}
+	logInfo("Config Reloaded:", path)
+	for {
+		select {
+		case g.reloads <- cfg:
+			return
+		default:
+		}
+		select {
+		case <-g.reloads:
+		default:
+		}
+	}
+}
+
+// applyReload swaps cfg in as the startup config, keeping an active
+// preset layered on top. Settings that need a restart keep their current
+// values. Called from Update with the lock held.
+func (g *Game) applyReload(cfg Config) {
+	live := keepStartup(cfg, g.baseCfg)
+	if !reflect.DeepEqual(live, cfg) {
+		logWarn("Config Reload: resolution, TPS, fonts, audio input and services need a restart")
+	}
+	g.baseCfg = live
+	if g.preset >= 0 {
+		g.applyPreset(g.preset)
+		return
+	}
+	g.fade = nil
+	g.setConfig(live)
+	g.vignette = nil
+}
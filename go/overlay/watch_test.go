@@ -0,0 +1,27 @@
+package overlay
+
+import "testing"
+
+// TestReloadAppliedInUpdate checks a reloaded config waits for Update,
+// and that a newer save replaces one still queued.
+func TestReloadAppliedInUpdate(t *testing.T) {
+	g, clock := newTestGame(DefaultConfig(), 1)
+	g.reloads = make(chan Config, 1)
+
+	g.reloadConfig(writeConfig(t, `{"grain_intensity": 0.3}`))
+	g.reloadConfig(writeConfig(t, `{"grain_intensity": 0.6, "width": 640}`))
+	if g.cfg.GrainIntensity != DefaultConfig().GrainIntensity {
+		t.Fatalf("config changed before Update: grain %v", g.cfg.GrainIntensity)
+	}
+
+	run(t, g, clock, 1)
+	if g.cfg.GrainIntensity != 0.6 {
+		t.Errorf("grain %v after Update, want the newest save's 0.6", g.cfg.GrainIntensity)
+	}
+	if g.cfg.Width != 1920 || g.baseCfg.Width != 1920 {
+		t.Errorf("reload changed the resolution to %d", g.cfg.Width)
+	}
+	if len(g.reloads) != 0 {
+		t.Error("a reload is still queued")
+	}
+}
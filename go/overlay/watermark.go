@@ -0,0 +1,52 @@
+package overlay
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// nextWatermark cycles through the configured watermarks, with one
+// extra step that hides it.
+func (g *Game) nextWatermark() {
+	if len(g.cfg.Watermarks) == 0 {
+		return
+	}
+	g.watermark = (g.watermark + 1) % (len(g.cfg.Watermarks) + 1)
+	g.watermarkImg = nil
+}
+
+// drawWatermark draws the current theme kanji huge and faint behind the
+// barrage, slowly turning and breathing with the mic.
+func (g *Game) drawWatermark(screen *ebiten.Image) {
+	g.mu.Lock() // Builds the cached image
+	defer g.mu.Unlock()
+
+	if g.watermarkFace == nil || g.watermark >= len(g.cfg.Watermarks) {
+		return
+	}
+	if g.watermarkImg == nil {
+		s := g.cfg.Watermarks[g.watermark]
+		rect := text.BoundString(g.watermarkFace, s)
+		if rect.Empty() {
+			return
+		}
+		g.watermarkImg = ebiten.NewImage(min(rect.Dx()+4, MaxWordImage), min(rect.Dy()+4, MaxWordImage))
+		text.Draw(g.watermarkImg, s, g.watermarkFace, 2-rect.Min.X, 2-rect.Min.Y, color.White)
+	}
+
+	w, h := g.watermarkImg.Bounds().Dx(), g.watermarkImg.Bounds().Dy()
+	breath := 1 + math.Min(g.micVolume, 1)*g.cfg.WatermarkBreath
+	theta := g.clock * g.cfg.WatermarkSpin
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(w)/2, -float64(h)/2)
+	op.GeoM.Scale(breath, breath)
+	op.GeoM.Rotate(theta)
+	op.GeoM.Translate(g.width/2, g.height/2)
+	op.Filter = ebiten.FilterLinear
+	op.ColorScale.ScaleAlpha(float32(g.cfg.WatermarkAlpha))
+	screen.DrawImage(g.watermarkImg, op)
+}
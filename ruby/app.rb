@@ -52,8 +52,8 @@ get '/cable' do
 
     ws.on :open do |event|
       $clients << ws
-      # Send current state
-      ws.send($manager.get_state.to_json)
+      # Send current state (or wait for the client's request_state)
+      ws.send($manager.get_state.to_json) if Config::SEND_STATE_ON_OPEN
     end
 
     ws.on :message do |event|
@@ -73,6 +73,10 @@ get '/cable' do
         
         $clients.each { |client| client.send(msg) }
         
+      when 'request_state'
+        # クライアントが(再)接続時に明示的に同期を要求
+        ws.send($manager.get_state.to_json)
+
       when 'vote'
         # Legacy/Mobile input (Keep only for manual override if needed)
         # $manager.add_vote(data['vote']) 
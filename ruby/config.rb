@@ -17,4 +17,7 @@ module Config
   
   # 投票の有効期間(秒)
   VOTE_WINDOW_SEC = 10
+
+  # 接続直後に現在の状態を送るか（false ならクライアントの request_state を待つ）
+  SEND_STATE_ON_OPEN = true
 end